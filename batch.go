@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// scanTargets scans every target in one pass over the Library and marks
+// findings shared with installed apps that are not being removed as well,
+// so a suite removed as a whole takes its shared folders with it.
+func scanTargets(targets, installed []AppInfo) {
+	apps := make([]*AppInfo, len(targets))
+	for i := range targets {
+		apps[i] = &targets[i]
+	}
+	interruptible(func(ctx context.Context) error { return scanAssociatedFiles(ctx, apps...) })
+
+	var remaining []AppInfo
+	for _, app := range installed {
+		if !slices.ContainsFunc(targets, func(t AppInfo) bool { return t.Path == app.Path }) {
+			remaining = append(remaining, app)
+		}
+	}
+	for i := range targets {
+		markShared(&targets[i], remaining)
+		markProtected(&targets[i])
+	}
+}
+
+// reviewTargets prints everything that is about to be removed and returns
+// the number of associated items.
+func reviewTargets(targets []AppInfo) int {
+	total := 0
+	for i, app := range targets {
+		if i > 0 {
+			fmt.Println()
+		}
+		printAppDetails(app)
+		for _, c := range app.categories() {
+			printCategory(c)
+			total += len(c.Items)
+		}
+	}
+	if total == 0 {
+		fmt.Println("\nNo associated items found.")
+	}
+	printReclaimable(targets)
+	return total
+}
+
+// deleteApps removes targets and their associated items after showing one
+// combined review and asking once. A target without a path is an app that
+// is already gone; only its leftovers are removed.
+func deleteApps(targets, installed []AppInfo) {
+	if err := checkConfirmable(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	checkProtected(targets)
+	scanTargets(targets, installed)
+	if !jsonOutput() {
+		reviewTargets(targets)
+		if !confirmDelete(len(targets), bufio.NewReader(os.Stdin)) {
+			fmt.Println("Cancelled.")
+			os.Exit(0)
+		}
+	}
+	chosen := chooseFindings(targets, nil)
+	if err := prepareTargets(targets, chosen, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	journal := startJournal()
+	out := newDeleteOutput(targets, journal)
+	for i, target := range targets {
+		if target.Path != "" {
+			result := removeAndReport(journal, target.Name, categoryApplication, target.Path)
+			out.Apps[i].Results = append(out.Apps[i].Results, result)
+			if result.Error != "" {
+				continue
+			}
+		}
+		out.Apps[i].Results = append(out.Apps[i].Results, removeFindings(journal, target.Name, chosen[i])...)
+	}
+
+	if jsonOutput() {
+		writeJSON(out)
+	} else {
+		finishBatch(targets, out, journal)
+	}
+	if out.failed() {
+		os.Exit(1)
+	}
+}
+
+// checkConfirmable makes sure a batch deletion can be confirmed. JSON output
+// shows no review and --from-file - has used up stdin, so both need --yes.
+func checkConfirmable() error {
+	if dryRun || assumeYes {
+		return nil
+	}
+	if jsonOutput() {
+		return fmt.Errorf("--output json shows no review to confirm; add --yes")
+	}
+	if fromFile == "-" {
+		return fmt.Errorf("--from-file - reads the list from stdin, so nothing is left to confirm with; add --yes")
+	}
+	return nil
+}
+
+// confirmDelete asks once whether to delete the n reviewed targets. A dry
+// run deletes nothing and --yes answers for scripts.
+func confirmDelete(n int, reader *bufio.Reader) bool {
+	if dryRun || assumeYes {
+		return true
+	}
+	if n == 1 {
+		fmt.Print("\nDelete this application and its associated items? (y/n): ")
+	} else {
+		fmt.Printf("\nDelete these %d applications and their associated items? (y/n): ", n)
+	}
+	line, _ := reader.ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(line)) == "y"
+}
+
+// chooseFindings picks each target's findings to act on, leaving out those
+// an earlier target in the batch already has. When confirm is set it is
+// asked about every item that is not kept.
+func chooseFindings(targets []AppInfo, confirm func(Finding) bool) [][]Finding {
+	handled := map[string]bool{}
+	chosen := make([][]Finding, len(targets))
+	for i, app := range targets {
+		for _, c := range app.categories() {
+			for _, f := range c.Items {
+				if handled[f.Path] {
+					continue
+				}
+				if !f.kept() && confirm != nil && !confirm(f) {
+					continue
+				}
+				handled[f.Path] = true
+				chosen[i] = append(chosen[i], f)
+			}
+		}
+	}
+	return chosen
+}
+
+// prepareTargets runs once every prompt is answered and before anything is
+// deleted. Running targets are checked first, since that may still abort,
+// so jobs are only unloaded when the deletion goes ahead.
+func prepareTargets(targets []AppInfo, chosen [][]Finding, reader *bufio.Reader) error {
+	running, err := findRunning(targets, reader)
+	if err != nil {
+		return err
+	}
+	unloadStartupItems(chosen)
+	return stopRunning(running)
+}
+
+// removeFindings removes the chosen findings of the app named appName.
+func removeFindings(journal *journalSession, appName string, findings []Finding) []deleteResult {
+	var results []deleteResult
+	for _, f := range findings {
+		results = append(results, removeFinding(journal, appName, f.Category, f))
+	}
+	return results
+}
+
+func finishBatch(targets []AppInfo, out deleteOutput, journal *journalSession) {
+	if len(targets) > 1 {
+		printSummary(targets, out)
+	}
+	if dryRun {
+		fmt.Println("\nDry run complete. No files were actually deleted.")
+	}
+	printUndoHint(journal)
+}
+
+// printSummary reports per app how many items were removed, kept or could
+// not be removed, and how much space that freed.
+func printSummary(targets []AppInfo, out deleteOutput) {
+	fmt.Println("\nSummary:")
+	for i, app := range targets {
+		sizes := map[string]int64{app.Path: app.Size}
+		for _, f := range app.Findings {
+			sizes[f.Path] = f.Size
+		}
+
+		var removed, kept, failed int
+		var freed int64
+		for _, r := range out.Apps[i].Results {
+			switch r.Action {
+			case "kept":
+				kept++
+			case "failed":
+				failed++
+			default:
+				removed++
+				freed += sizes[r.Path]
+			}
+		}
+		fmt.Printf("  %s: %d removed, %d kept, %d failed, %s\n", app.Name, removed, kept, failed, formatSize(freed))
+	}
+}
+
+// parseSelection turns input such as "1,4,7-9" into zero-based indexes
+// into a list of n entries, in the order given and without duplicates.
+func parseSelection(input string, n int) ([]int, error) {
+	var indexes []int
+	fields := strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	for _, field := range fields {
+		first, last := field, field
+		if i := strings.Index(field, "-"); i > 0 {
+			first, last = field[:i], field[i+1:]
+		}
+		lo, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", field)
+		}
+		hi, err := strconv.Atoi(last)
+		if err != nil || hi < lo {
+			return nil, fmt.Errorf("invalid selection %q", field)
+		}
+		if lo < 1 || hi > n {
+			return nil, fmt.Errorf("selection %q is out of range 1-%d", field, n)
+		}
+		for i := lo - 1; i < hi; i++ {
+			if !slices.Contains(indexes, i) {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := map[string][]int{
+		"1":        {0},
+		"1,4,7-9":  {0, 3, 6, 7, 8},
+		" 2 3, 2 ": {1, 2},
+		"9-10,1":   {8, 9, 0},
+		"":         nil,
+		"5-5":      {4},
+		"3,1-3":    {2, 0, 1},
+	}
+	for in, want := range tests {
+		got, err := parseSelection(in, 10)
+		if err != nil {
+			t.Errorf("parseSelection(%q): unexpected error: %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parseSelection(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"0", "11", "a", "3-1", "1-", "-2", "2-x", "8 - 9"} {
+		if _, err := parseSelection(in, 10); err == nil {
+			t.Errorf("parseSelection(%q): expected an error", in)
+		}
+	}
+}
+
+func TestReadTargetList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apps.txt")
+	data := "# apps to remove\nSlack\n\n  Google Chrome  \ncom.gone.app\n/Volumes/Foo/Foo.app\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+
+	got, err := readTargetList(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"Slack", "Google Chrome", "com.gone.app", "/Volumes/Foo/Foo.app"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := readTargetList(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing list")
+	}
+}
+
+func TestDeleteAppsBatch(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	outputFormat, assumeYes = "json", true
+	defer func() { outputFormat, assumeYes = "text", false }()
+
+	wordPath := fs.createApp(t, "Microsoft Word", "com.microsoft.Word")
+	excelPath := fs.createApp(t, "Microsoft Excel", "com.microsoft.Excel")
+	fs.createApp(t, "TestApp", "com.test.app")
+	vendorDir := fs.createAppSupportDir(t, "Microsoft")
+	wordPref := fs.createPrefFile(t, "com.microsoft.Word", ".plist")
+	excelPref := fs.createPrefFile(t, "com.microsoft.Excel", ".plist")
+	testPref := fs.createPrefFile(t, "com.test.app", ".plist")
+
+	targets, installed := resolveTargets([]string{"Microsoft Word", "com.microsoft.Excel", "microsoft word"}, nil)
+	if len(targets) != 2 {
+		t.Fatalf("expected duplicate targets to be dropped, got %d", len(targets))
+	}
+
+	deleteApps(targets, installed)
+
+	// With the whole suite removed, its shared folder goes too.
+	for _, path := range []string{wordPath, excelPath, vendorDir, wordPref, excelPref} {
+		if exists, _ := pathExists(path); exists {
+			t.Errorf("expected %s to be removed", path)
+		}
+	}
+	if exists, _ := pathExists(testPref); !exists {
+		t.Error("apps outside the batch should be left alone")
+	}
+}
+
+func TestConfirmDelete(t *testing.T) {
+	defer func() { assumeYes, dryRun = false, false }()
+
+	for in, want := range map[string]bool{"y\n": true, "Y\n": true, "n\n": false, "": false} {
+		if got := confirmDelete(2, bufio.NewReader(strings.NewReader(in))); got != want {
+			t.Errorf("confirmDelete(%q) = %v, want %v", in, got, want)
+		}
+	}
+	assumeYes = true
+	if !confirmDelete(2, bufio.NewReader(strings.NewReader(""))) {
+		t.Error("expected --yes to confirm without asking")
+	}
+}
+
+func TestCheckConfirmable(t *testing.T) {
+	defer func() { outputFormat, fromFile, assumeYes, dryRun = "text", "", false, false }()
+
+	if err := checkConfirmable(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	outputFormat = "json"
+	if err := checkConfirmable(); err == nil {
+		t.Error("expected --output json to require --yes")
+	}
+	dryRun = true
+	if err := checkConfirmable(); err != nil {
+		t.Errorf("expected a dry run to need no confirmation, got %v", err)
+	}
+	outputFormat, dryRun, fromFile = "text", false, "-"
+	if err := checkConfirmable(); err == nil {
+		t.Error("expected --from-file - to require --yes")
+	}
+	assumeYes = true
+	if err := checkConfirmable(); err != nil {
+		t.Errorf("unexpected error with --yes: %v", err)
+	}
+}
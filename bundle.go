@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type DocumentType struct {
+	Name         string
+	Role         string
+	Extensions   []string
+	ContentTypes []string
+}
+
+type URLType struct {
+	Name    string
+	Schemes []string
+}
+
+func loadBundleInfo(app *AppInfo) error {
+	info, err := readPlistDict(filepath.Join(app.Path, "Contents", "Info.plist"))
+	if err != nil {
+		return err
+	}
+
+	app.BundleID = strings.TrimSpace(plistString(info, "CFBundleIdentifier"))
+	app.BundleName = plistString(info, "CFBundleName")
+	app.DisplayName = plistString(info, "CFBundleDisplayName")
+	app.Version = plistString(info, "CFBundleShortVersionString")
+	app.BuildVersion = plistString(info, "CFBundleVersion")
+	app.Executable = plistString(info, "CFBundleExecutable")
+	app.MinimumSystemVersion = plistString(info, "LSMinimumSystemVersion")
+	app.FeedURL = plistString(info, "SUFeedURL")
+
+	app.DocumentTypes = nil
+	for _, v := range plistArray(info, "CFBundleDocumentTypes") {
+		d, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		app.DocumentTypes = append(app.DocumentTypes, DocumentType{
+			Name:         plistString(d, "CFBundleTypeName"),
+			Role:         plistString(d, "CFBundleTypeRole"),
+			Extensions:   plistStrings(d, "CFBundleTypeExtensions"),
+			ContentTypes: plistStrings(d, "LSItemContentTypes"),
+		})
+	}
+
+	app.URLTypes = nil
+	for _, v := range plistArray(info, "CFBundleURLTypes") {
+		d, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		app.URLTypes = append(app.URLTypes, URLType{
+			Name:    plistString(d, "CFBundleURLName"),
+			Schemes: plistStrings(d, "CFBundleURLSchemes"),
+		})
+	}
+
+	loadSignature(app)
+	return nil
+}
+
+func plistArray(dict map[string]any, key string) []any {
+	a, _ := dict[key].([]any)
+	return a
+}
+
+func plistStrings(dict map[string]any, key string) []string {
+	var out []string
+	for _, v := range plistArray(dict, key) {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// appLabel names an app the way the menu and --list show it, e.g.
+// "Foo 2.3" or "Foo 3.0 (Foo Pro)" when the display name differs.
+func appLabel(app AppInfo) string {
+	label := app.Name
+	if v := app.versionString(); v != "" {
+		label += " " + v
+	}
+	if app.DisplayName != "" && app.DisplayName != app.Name {
+		label += " (" + app.DisplayName + ")"
+	}
+	return label
+}
+
+// menuLabel adds the containing folder to apps whose name is not unique.
+func menuLabel(app AppInfo, apps []AppInfo) string {
+	for _, other := range apps {
+		if other.Path != app.Path && strings.EqualFold(other.Name, app.Name) {
+			return appLabel(app) + "  [" + displayDir(app.Path) + "]"
+		}
+	}
+	return appLabel(app)
+}
+
+// displayDir shows the folder containing path, abbreviating the home
+// directory to ~.
+func displayDir(path string) string {
+	dir := filepath.Dir(path)
+	home := homeDir()
+	if home != "" && (dir == home || strings.HasPrefix(dir, home+string(filepath.Separator))) {
+		return "~" + strings.TrimPrefix(dir, home)
+	}
+	return dir
+}
+
+func (app AppInfo) versionString() string {
+	if app.Version != "" {
+		return app.Version
+	}
+	return app.BuildVersion
+}
+
+func printAppDetails(app AppInfo) {
+	fmt.Printf("Selected: %s\n", app.Name)
+	if app.Path != "" {
+		fmt.Printf("Location: %s\n", app.Path)
+	} else {
+		fmt.Println("Location: not installed")
+	}
+	if app.DisplayName != "" && app.DisplayName != app.Name {
+		fmt.Printf("Display name: %s\n", app.DisplayName)
+	}
+	if v := app.versionString(); v != "" {
+		if app.Version != "" && app.BuildVersion != "" && app.BuildVersion != app.Version {
+			v += " (" + app.BuildVersion + ")"
+		}
+		fmt.Printf("Version: %s\n", v)
+	}
+
+	if !verbose {
+		return
+	}
+	if app.BundleName != "" {
+		fmt.Printf("Bundle name: %s\n", app.BundleName)
+	}
+	if app.Executable != "" {
+		fmt.Printf("Executable: %s\n", app.Executable)
+	}
+	if app.MinimumSystemVersion != "" {
+		fmt.Printf("Minimum macOS: %s\n", app.MinimumSystemVersion)
+	}
+	if app.FeedURL != "" {
+		fmt.Printf("Update feed: %s\n", app.FeedURL)
+	}
+	if app.TeamID != "" {
+		fmt.Printf("Signed by team: %s (%s)\n", app.TeamID, app.SigningID)
+	}
+	for _, g := range app.AppGroups {
+		fmt.Printf("App group: %s\n", g)
+	}
+	for _, d := range app.DocumentTypes {
+		types := append(append([]string(nil), d.Extensions...), d.ContentTypes...)
+		fmt.Printf("Document type: %s [%s] %s\n", d.Name, d.Role, strings.Join(types, ", "))
+	}
+	for _, u := range app.URLTypes {
+		fmt.Printf("URL type: %s %s\n", u.Name, strings.Join(u.Schemes, ", "))
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadBundleInfo(t *testing.T) {
+	fs := newTestFS(t)
+
+	appPath := fs.createApp(t, "Foo", "com.test.foo")
+	infoPlist := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>com.test.foo</string>
+	<key>CFBundleName</key>
+	<string>Foo</string>
+	<key>CFBundleDisplayName</key>
+	<string>Foo Pro</string>
+	<key>CFBundleShortVersionString</key>
+	<string>2.3</string>
+	<key>CFBundleVersion</key>
+	<string>2301</string>
+	<key>CFBundleExecutable</key>
+	<string>FooBin</string>
+	<key>LSMinimumSystemVersion</key>
+	<string>12.0</string>
+	<key>SUFeedURL</key>
+	<string>https://example.com/appcast.xml</string>
+	<key>CFBundleDocumentTypes</key>
+	<array>
+		<dict>
+			<key>CFBundleTypeName</key>
+			<string>Foo Document</string>
+			<key>CFBundleTypeRole</key>
+			<string>Editor</string>
+			<key>CFBundleTypeExtensions</key>
+			<array><string>foo</string></array>
+			<key>LSItemContentTypes</key>
+			<array><string>com.test.foo.document</string></array>
+		</dict>
+	</array>
+	<key>CFBundleURLTypes</key>
+	<array>
+		<dict>
+			<key>CFBundleURLName</key>
+			<string>Foo Link</string>
+			<key>CFBundleURLSchemes</key>
+			<array><string>foo</string><string>foo-app</string></array>
+		</dict>
+	</array>
+</dict>
+</plist>`
+	if err := os.WriteFile(filepath.Join(appPath, "Contents", "Info.plist"), []byte(infoPlist), 0644); err != nil {
+		t.Fatalf("failed to write Info.plist: %v", err)
+	}
+
+	app := AppInfo{Name: "Foo", Path: appPath}
+	if err := loadBundleInfo(&app); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := AppInfo{
+		Name:                 "Foo",
+		Path:                 appPath,
+		BundleID:             "com.test.foo",
+		BundleName:           "Foo",
+		DisplayName:          "Foo Pro",
+		Version:              "2.3",
+		BuildVersion:         "2301",
+		Executable:           "FooBin",
+		MinimumSystemVersion: "12.0",
+		FeedURL:              "https://example.com/appcast.xml",
+		DocumentTypes: []DocumentType{{
+			Name:         "Foo Document",
+			Role:         "Editor",
+			Extensions:   []string{"foo"},
+			ContentTypes: []string{"com.test.foo.document"},
+		}},
+		URLTypes: []URLType{{Name: "Foo Link", Schemes: []string{"foo", "foo-app"}}},
+	}
+	if !reflect.DeepEqual(app, want) {
+		t.Errorf("got %+v, want %+v", app, want)
+	}
+
+	if got := appLabel(app); got != "Foo 2.3 (Foo Pro)" {
+		t.Errorf("expected label %q, got %q", "Foo 2.3 (Foo Pro)", got)
+	}
+}
+
+func TestAppLabel(t *testing.T) {
+	tests := []struct {
+		app  AppInfo
+		want string
+	}{
+		{AppInfo{Name: "Foo"}, "Foo"},
+		{AppInfo{Name: "Foo", Version: "3.0", DisplayName: "Foo"}, "Foo 3.0"},
+		{AppInfo{Name: "Foo", BuildVersion: "512"}, "Foo 512"},
+	}
+	for _, tt := range tests {
+		if got := appLabel(tt.app); got != tt.want {
+			t.Errorf("appLabel(%+v) = %q, want %q", tt.app, got, tt.want)
+		}
+	}
+}
+
+func TestGetApplicationsLoadsBundleInfo(t *testing.T) {
+	fs := newTestFS(t)
+	fs.createApp(t, "TestApp", "com.test.app")
+
+	apps, err := getApplications(filepath.Join(fs.homeDir, "Applications"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apps) != 1 {
+		t.Fatalf("expected 1 app, got %d", len(apps))
+	}
+	if apps[0].BundleID != "com.test.app" || apps[0].Executable != "TestApp" {
+		t.Errorf("expected bundle info to be loaded, got %+v", apps[0])
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// A config is read from the system-wide and per-user config.yaml files.
+// Lists from every file are combined; defaults in later files win.
+type config struct {
+	// Locations are extra folders to search, either added to an existing
+	// category or forming a new one.
+	Locations []configLocation `yaml:"locations"`
+	// Exclude lists globs for paths that are never deleted.
+	Exclude []string `yaml:"exclude"`
+	// Protected lists bundle IDs or app names treated like Apple apps.
+	Protected []string `yaml:"protected"`
+	// Defaults sets flags that were not given on the command line.
+	Defaults map[string]any `yaml:"defaults"`
+}
+
+type configLocation struct {
+	Path     string   `yaml:"path"`
+	Category string   `yaml:"category"`
+	Title    string   `yaml:"title"`
+	Risk     string   `yaml:"risk"`
+	Match    []string `yaml:"match"`
+}
+
+var cfg config
+
+// configPaths lists the config files in the order they are applied.
+func configPaths() []string {
+	return []string{
+		"/etc/zaap/config.yaml",
+		"/Library/Preferences/zaap/config.yaml",
+		filepath.Join(os.Getenv("HOME"), ".config", "zaap", "config.yaml"),
+	}
+}
+
+// loadConfig reads and combines the files in paths, skipping any that do
+// not exist.
+func loadConfig(paths ...string) (config, error) {
+	merged := config{Defaults: map[string]any{}}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return config{}, err
+		}
+
+		var c config
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&c); err != nil && err != io.EOF {
+			return config{}, fmt.Errorf("%s: %w", path, err)
+		}
+		merged.Locations = append(merged.Locations, c.Locations...)
+		merged.Exclude = append(merged.Exclude, c.Exclude...)
+		merged.Protected = append(merged.Protected, c.Protected...)
+		for name, value := range c.Defaults {
+			merged.Defaults[name] = value
+		}
+	}
+	return merged, nil
+}
+
+// setupConfig loads the config files, applies their defaults to cmd,
+// registers their locations and loads the rules.
+func setupConfig(cmd *cobra.Command) error {
+	c, err := loadConfig(configPaths()...)
+	if err != nil {
+		return err
+	}
+	cfg = c
+	if err := applyDefaults(cmd, cfg.Defaults); err != nil {
+		return err
+	}
+	if err := registerConfigLocations(cfg.Locations); err != nil {
+		return err
+	}
+	// Rules are checked against the scanner locations, so they come last.
+	return loadRules(rulesDirs()...)
+}
+
+// applyDefaults sets every flag named in defaults that was not given on the
+// command line. Flags that do not apply to cmd are ignored, but names that
+// are not flags of any command are an error.
+func applyDefaults(cmd *cobra.Command, defaults map[string]any) error {
+	for name, value := range defaults {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			flag = cmd.InheritedFlags().Lookup(name)
+		}
+		if flag == nil {
+			if !isFlagName(cmd.Root(), name) {
+				return fmt.Errorf("config: unknown default %q", name)
+			}
+			continue
+		}
+		if flag.Changed {
+			continue
+		}
+
+		values := []any{value}
+		if list, ok := value.([]any); ok {
+			values = list
+		}
+		for _, v := range values {
+			if err := flag.Value.Set(fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("config: default %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isFlagName(cmd *cobra.Command, name string) bool {
+	found := false
+	visit := func(f *pflag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	}
+	cmd.Flags().VisitAll(visit)
+	cmd.PersistentFlags().VisitAll(visit)
+	for _, sub := range cmd.Commands() {
+		if isFlagName(sub, name) {
+			return true
+		}
+	}
+	return found
+}
+
+// registerConfigLocations adds each location to the scanner for its
+// category, registering a new scanner for categories zaap does not have.
+func registerConfigLocations(locations []configLocation) error {
+	for _, cl := range locations {
+		loc, err := cl.location()
+		if err != nil {
+			return err
+		}
+
+		id := cl.Category
+		if id == "" {
+			id = "custom-locations"
+		}
+		if s, ok := scannerByID(id).(*dirScanner); ok {
+			s.locations = append(s.locations, loc)
+			continue
+		}
+		if scannerByID(id) != nil {
+			return fmt.Errorf("config: cannot add locations to category %s", id)
+		}
+
+		title := cl.Title
+		if title == "" {
+			title = "Custom locations"
+		}
+		risk := riskMedium
+		if cl.Risk != "" {
+			if risk, err = parseRisk(cl.Risk); err != nil {
+				return err
+			}
+		}
+		registerScanner(&dirScanner{id: id, name: title, risk: risk, locations: []location{loc}})
+	}
+	return nil
+}
+
+func (cl configLocation) location() (location, error) {
+	rules := []matchRule{matchBundleID, matchBundlePrefix}
+	if len(cl.Match) > 0 {
+		rules = nil
+		for _, name := range cl.Match {
+			rule, err := parseMatchRule(name)
+			if err != nil {
+				return location{}, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(cl.Path, "~/"):
+		return homeLocation(strings.TrimPrefix(cl.Path, "~/"), rules...), nil
+	case filepath.IsAbs(cl.Path):
+		return systemLocation(cl.Path, rules...), nil
+	}
+	return location{}, fmt.Errorf("config: location %q must be absolute or start with ~/", cl.Path)
+}
+
+func parseMatchRule(name string) (matchRule, error) {
+	for _, rule := range matchRules {
+		if rule.String() == name {
+			return rule, nil
+		}
+	}
+	return 0, fmt.Errorf("config: unknown match rule %q", name)
+}
+
+func parseRisk(name string) (riskLevel, error) {
+	for _, risk := range []riskLevel{riskLow, riskMedium, riskHigh} {
+		if risk.String() == name {
+			return risk, nil
+		}
+	}
+	return 0, fmt.Errorf("config: unknown risk %q", name)
+}
+
+// excluded reports whether path matches an exclude glob, lies inside an
+// excluded folder or contains something excluded. Globs without a slash
+// match the file name; ~/ is the target home folder and absolute globs are
+// rebased onto --root.
+func excluded(path string) bool {
+	for _, pattern := range cfg.Exclude {
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+				return true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(pattern, "~/") {
+			pattern = homePath(strings.TrimPrefix(pattern, "~/"))
+		} else {
+			pattern = systemPath(pattern)
+		}
+		pattern = strings.TrimSuffix(pattern, "/**")
+
+		for dir := path; ; dir = filepath.Dir(dir) {
+			if ok, _ := filepath.Match(pattern, dir); ok {
+				return true
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+
+		literal := pattern
+		if i := strings.IndexAny(pattern, "*?[\\"); i >= 0 {
+			literal = filepath.Dir(pattern[:i] + "x")
+		}
+		if literal != path && isUnder(literal, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// configProtected reports whether the config protects app by bundle ID or
+// name.
+func configProtected(app AppInfo) bool {
+	return slices.ContainsFunc(cfg.Protected, func(p string) bool {
+		return strings.EqualFold(p, app.BundleID) || strings.EqualFold(p, app.Name)
+	})
+}
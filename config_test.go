@@ -0,0 +1,202 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	system := filepath.Join(dir, "etc", "config.yaml")
+	user := filepath.Join(dir, "home", "config.yaml")
+	writeConfig(t, system, `
+exclude: ["~/Library/Application Support/Shared/**"]
+protected: [com.corp.vpn]
+defaults:
+  dry-run: true
+  permanent: true
+`)
+	writeConfig(t, user, `
+exclude: ["*.keychain"]
+defaults:
+  permanent: false
+`)
+
+	c, err := loadConfig(system, filepath.Join(dir, "missing.yaml"), user)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.Exclude) != 2 || len(c.Protected) != 1 {
+		t.Errorf("expected lists from both files, got %+v", c)
+	}
+	if c.Defaults["dry-run"] != true || c.Defaults["permanent"] != false {
+		t.Errorf("expected user defaults to override system ones, got %v", c.Defaults)
+	}
+
+	writeConfig(t, user, "exlcude: [foo]\n")
+	if _, err := loadConfig(user); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	var dry bool
+	var names []string
+	root := &cobra.Command{Use: "zaap"}
+	root.PersistentFlags().BoolVar(&dry, "dry-run", false, "")
+	sub := &cobra.Command{Use: "rm"}
+	sub.Flags().StringArrayVar(&names, "bundle-id", nil, "")
+	other := &cobra.Command{Use: "orphans"}
+	other.Flags().Bool("list", false, "")
+	root.AddCommand(sub, other)
+
+	defaults := map[string]any{"dry-run": true, "bundle-id": []any{"com.a", "com.b"}, "list": true}
+	if err := applyDefaults(sub, defaults); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !dry || len(names) != 2 {
+		t.Errorf("expected defaults to be applied, got dry-run=%v bundle-id=%v", dry, names)
+	}
+
+	dry = false
+	root.PersistentFlags().Lookup("dry-run").Changed = true
+	if err := applyDefaults(sub, map[string]any{"dry-run": true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dry {
+		t.Error("expected a flag given on the command line to win")
+	}
+
+	if err := applyDefaults(sub, map[string]any{"dryrun": true}); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+}
+
+func TestRegisterConfigLocations(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+	saved := scanners
+	defer func() { scanners = saved }()
+	scanners = append([]Scanner(nil), scanners...)
+	if s, ok := scannerByID("caches").(*dirScanner); ok {
+		copied := *s
+		copied.locations = append([]location(nil), s.locations...)
+		for i := range scanners {
+			if scanners[i] == s {
+				scanners[i] = &copied
+			}
+		}
+	}
+
+	err := registerConfigLocations([]configLocation{
+		{Path: "~/Library/Corp", Match: []string{"bundle-id", "name"}},
+		{Path: "~/Library/CorpCache", Category: "caches"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, dir := range []string{"Corp", "CorpCache"} {
+		if err := os.MkdirAll(filepath.Join(fs.homeDir, "Library", dir, "Test App"), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Join(fs.homeDir, "Library", dir, "com.test.app"), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+
+	app := AppInfo{Name: "Test App", BundleID: "com.test.app", Path: fs.createApp(t, "Test App", "com.test.app")}
+	custom := scannerByID("custom-locations").Scan(newLibraryIndex(), &app, newMatcher(&app, app.BundleID))
+	if len(custom) != 2 {
+		t.Errorf("expected bundle ID and name matches in the custom location, got %+v", custom)
+	}
+	caches := scannerByID("caches").Scan(newLibraryIndex(), &app, newMatcher(&app, app.BundleID))
+	found := false
+	for _, f := range caches {
+		if f.Path == filepath.Join(fs.homeDir, "Library", "CorpCache", "com.test.app") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the caches category to search the added location, got %+v", caches)
+	}
+
+	for _, bad := range []configLocation{
+		{Path: "Library/Relative"},
+		{Path: "~/x", Match: []string{"fuzzy"}},
+		{Path: "~/x", Category: "new", Risk: "extreme"},
+	} {
+		if err := registerConfigLocations([]configLocation{bad}); err == nil {
+			t.Errorf("expected an error for %+v", bad)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+	defer func() { cfg = config{} }()
+	cfg = config{Exclude: []string{
+		"~/Library/Application Support/Shared/**",
+		"~/Library/Preferences/com.corp.*.plist",
+		"*.keychain",
+	}}
+
+	support := filepath.Join(fs.homeDir, "Library", "Application Support")
+	prefs := filepath.Join(fs.homeDir, "Library", "Preferences")
+	tests := []struct {
+		path     string
+		excluded bool
+	}{
+		{filepath.Join(support, "Shared"), true},
+		{filepath.Join(support, "Shared", "data.db"), true},
+		{filepath.Join(support, "SharedOther"), false},
+		{filepath.Join(prefs, "com.corp.vpn.plist"), true},
+		{filepath.Join(prefs, "com.test.app.plist"), false},
+		{filepath.Join(fs.homeDir, "Library", "Caches", "login.keychain"), true},
+		{filepath.Join(fs.homeDir, "Library"), true},
+	}
+	for _, tt := range tests {
+		if got := excluded(tt.path); got != tt.excluded {
+			t.Errorf("excluded(%s) = %v, want %v", tt.path, got, tt.excluded)
+		}
+	}
+
+	f := Finding{Path: filepath.Join(support, "Shared")}
+	if !f.kept() {
+		t.Error("expected an excluded finding to be kept")
+	}
+	if err := checkRemovable(filepath.Join(support, "Shared", "data.db")); err == nil {
+		t.Error("expected checkRemovable to refuse an excluded path")
+	}
+}
+
+func TestConfigProtected(t *testing.T) {
+	defer func() { cfg = config{} }()
+	cfg = config{Protected: []string{"com.corp.vpn", "Corp Agent"}}
+	os.Unsetenv("__CFBundleIdentifier")
+
+	for _, app := range []AppInfo{
+		{Name: "VPN", BundleID: "com.corp.VPN"},
+		{Name: "corp agent", BundleID: "com.corp.agent"},
+	} {
+		if protectionReason(app) == "" {
+			t.Errorf("expected %s to be protected", app.Name)
+		}
+	}
+	if reason := protectionReason(AppInfo{Name: "Other", BundleID: "com.corp.other"}); reason != "" {
+		t.Errorf("expected Other not to be protected, got %q", reason)
+	}
+}
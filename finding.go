@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A Finding is one item on disk that a scanner attributed to an app.
+type Finding struct {
+	Path       string
+	Category   string
+	Rule       matchRule
+	Confidence confidence
+	Size       int64
+	ModTime    time.Time
+	Type       string
+	// SharedWith names other installed apps that match this item at least
+	// as specifically.
+	SharedWith []string
+	// Helper is the bundle ID of the helper inside the app that the item
+	// was matched by, if it was not the app itself.
+	Helper string
+	// Key is the launchd plist key that matched, if the match was made on
+	// the plist's contents.
+	Key string
+	// Protected says why the item belongs to the system, if it does.
+	Protected string
+}
+
+type confidence int
+
+const (
+	confidenceLow confidence = iota
+	confidenceMedium
+	confidenceHigh
+)
+
+func (c confidence) String() string {
+	switch c {
+	case confidenceLow:
+		return "low"
+	case confidenceMedium:
+		return "medium"
+	}
+	return "high"
+}
+
+// bundleExtensions are directory extensions that macOS treats as a single
+// item rather than a folder.
+var bundleExtensions = map[string]bool{
+	".app": true, ".appex": true, ".bundle": true, ".framework": true,
+	".kext": true, ".mdimporter": true, ".plugin": true, ".prefPane": true,
+	".qlgenerator": true, ".saver": true, ".savedState": true, ".xpc": true,
+}
+
+func isBundleExtension(ext string) bool {
+	for e := range bundleExtensions {
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func newFinding(path, category string, rule matchRule) Finding {
+	return Finding{Path: path, Category: category, Rule: rule, Confidence: rule.confidence()}
+}
+
+// describe fills in disk usage, modification time and type from disk.
+func (f *Finding) describe() {
+	info, err := os.Lstat(f.Path)
+	if err != nil {
+		return
+	}
+	f.ModTime = info.ModTime()
+	f.Size = diskUsage(f.Path)
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		f.Type = "symlink"
+	case info.IsDir():
+		f.Type = "directory"
+		if isBundleExtension(filepath.Ext(f.Path)) {
+			f.Type = "bundle"
+		}
+	default:
+		f.Type = "file"
+	}
+}
+
+// normalizeFindings drops duplicate paths, keeping the most confident match,
+// and collapses anything nested inside another finding into its parent.
+func normalizeFindings(findings []Finding) []Finding {
+	best := map[string]int{}
+	var unique []Finding
+	for _, f := range findings {
+		f.Path = filepath.Clean(f.Path)
+		if i, ok := best[f.Path]; ok {
+			if f.Confidence > unique[i].Confidence {
+				unique[i] = f
+			}
+			continue
+		}
+		best[f.Path] = len(unique)
+		unique = append(unique, f)
+	}
+
+	var out []Finding
+	for _, f := range unique {
+		nested := false
+		for _, other := range unique {
+			if other.Path != f.Path && isUnder(f.Path, other.Path) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// describeFindings describes findings in place on a bounded pool of workers.
+func describeFindings(ctx context.Context, findings []Finding) error {
+	return parallel(ctx, len(findings), func(i int) {
+		findings[i].describe()
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeFindings(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	prefPath := fs.createPrefFile(t, "com.test.app", ".plist")
+	supportPath := fs.createAppSupportDir(t, "TestApp")
+	nestedPath := filepath.Join(supportPath, "cache")
+	if err := os.MkdirAll(nestedPath, 0755); err != nil {
+		t.Fatalf("failed to create nested dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(nestedPath, "data"), []byte("12345"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	panePath := fs.createPrefPane(t, "TestApp")
+
+	findings := normalizeFindings([]Finding{
+		newFinding(prefPath, "associated-files", matchBundlePrefix),
+		newFinding(prefPath, "associated-files", matchBundleID),
+		newFinding(nestedPath, "associated-files", matchName),
+		newFinding(supportPath, "associated-files", matchName),
+		newFinding(supportPath+"/", "associated-files", matchName),
+		newFinding(panePath, "control-panels", matchName),
+	})
+	if err := describeFindings(context.Background(), findings); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %d: %+v", len(findings), findings)
+	}
+
+	byPath := map[string]Finding{}
+	for _, f := range findings {
+		byPath[f.Path] = f
+	}
+
+	pref, ok := byPath[prefPath]
+	if !ok {
+		t.Fatalf("expected %s", prefPath)
+	}
+	if pref.Rule != matchBundleID || pref.Confidence != confidenceHigh {
+		t.Errorf("expected the exact bundle ID match to win, got %+v", pref)
+	}
+	if pref.Type != "file" || pref.Size < 4 || pref.ModTime.IsZero() {
+		t.Errorf("expected file details, got %+v", pref)
+	}
+
+	support, ok := byPath[supportPath]
+	if !ok {
+		t.Fatalf("expected %s with nested path collapsed into it", supportPath)
+	}
+	if support.Type != "directory" || support.Size < 5 {
+		t.Errorf("expected directory including nested data, got %+v", support)
+	}
+	if _, ok := byPath[nestedPath]; ok {
+		t.Errorf("nested path %s should have been collapsed", nestedPath)
+	}
+
+	if byPath[panePath].Type != "bundle" {
+		t.Errorf("expected prefPane to be a bundle, got %s", byPath[panePath].Type)
+	}
+}
+
+func TestNormalizeFindingsSiblingSortsBetween(t *testing.T) {
+	// "Foo.bak" sorts between "Foo" and "Foo/cache" as plain strings.
+	findings := normalizeFindings([]Finding{
+		newFinding("/x/Foo", "associated-files", matchName),
+		newFinding("/x/Foo.bak", "associated-files", matchName),
+		newFinding("/x/Foo/cache", "associated-files", matchName),
+		newFinding("/x/Foo bar/cache", "associated-files", matchName),
+		newFinding("/x/Foo bar", "associated-files", matchName),
+	})
+
+	var paths []string
+	for _, f := range findings {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"/x/Foo", "/x/Foo.bak", "/x/Foo bar"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected %v, got %v", want, paths)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:             "0 B",
+		999:           "999 B",
+		1000:          "1.0 kB",
+		1500000:       "1.5 MB",
+		3400000000:    "3.4 GB",
+		1200000000000: "1.2 TB",
+	}
+	for n, want := range tests {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
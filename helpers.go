@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A helperBundle is a bundle nested in an app that has its own bundle ID,
+// such as a login item, XPC service or privileged helper.
+type helperBundle struct {
+	// Path is relative to the app bundle.
+	Path     string
+	BundleID string
+}
+
+// helperPatterns are the places inside an app bundle that hold helpers.
+var helperPatterns = []string{
+	"Contents/Library/LoginItems/*.app",
+	"Contents/XPCServices/*.xpc",
+	"Contents/PlugIns/*.appex",
+	"Contents/Frameworks/*Helper*.app",
+	"Contents/Library/LaunchServices/*",
+}
+
+// findHelpers collects the bundle IDs of the helpers inside the app at
+// appPath, leaving out ones equal to mainID.
+func findHelpers(appPath, mainID string) []helperBundle {
+	seen := map[string]bool{strings.ToLower(mainID): true}
+	var helpers []helperBundle
+	for _, pattern := range helperPatterns {
+		paths, _ := filepath.Glob(filepath.Join(appPath, pattern))
+		for _, path := range paths {
+			id := helperBundleID(path)
+			if id == "" || seen[strings.ToLower(id)] {
+				continue
+			}
+			seen[strings.ToLower(id)] = true
+			rel, _ := filepath.Rel(appPath, path)
+			helpers = append(helpers, helperBundle{Path: rel, BundleID: id})
+		}
+	}
+	return helpers
+}
+
+// helperBundleID reads the bundle ID of a nested bundle, or of a bare
+// privileged helper tool from its embedded Info.plist. Such tools are
+// named after their bundle ID, which serves when there is no Info.plist.
+func helperBundleID(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return getBundleID(path)
+	}
+	if dict, err := embeddedInfoPlist(path); err == nil {
+		if id := strings.TrimSpace(plistString(dict, "CFBundleIdentifier")); id != "" {
+			return id
+		}
+	}
+	if name := filepath.Base(path); isReverseDNS(strings.Split(strings.ToLower(name), ".")) {
+		return name
+	}
+	return ""
+}
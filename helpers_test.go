@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindHelpers(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	appPath := fs.createApp(t, "Test App", "com.test.app")
+	nested := map[string]string{
+		"Contents/Library/LoginItems/Launcher.app":  "com.test.launcher",
+		"Contents/XPCServices/Fetch.xpc":            "com.test.app.fetch",
+		"Contents/PlugIns/Share.appex":              "com.test.share",
+		"Contents/Frameworks/Test App Helper.app":   "com.test.app",
+		"Contents/Frameworks/Test App Renderer.app": "com.test.renderer",
+		"Contents/Library/LoginItems/Duplicate.app": "com.test.launcher",
+	}
+	for rel, id := range nested {
+		writeInfoPlist(t, filepath.Join(appPath, rel), id)
+	}
+	tools := filepath.Join(appPath, "Contents", "Library", "LaunchServices")
+	if err := os.MkdirAll(tools, 0755); err != nil {
+		t.Fatalf("failed to create LaunchServices: %v", err)
+	}
+	for _, name := range []string{"com.test.privileged", "installer"} {
+		if err := os.WriteFile(filepath.Join(tools, name), []byte("test"), 0755); err != nil {
+			t.Fatalf("failed to create helper tool: %v", err)
+		}
+	}
+
+	got := map[string]bool{}
+	for _, h := range findHelpers(appPath, "com.test.app") {
+		got[h.BundleID] = true
+	}
+	want := []string{"com.test.launcher", "com.test.app.fetch", "com.test.share", "com.test.privileged"}
+	if len(got) != len(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for _, id := range want {
+		if !got[id] {
+			t.Errorf("missing helper %s", id)
+		}
+	}
+}
+
+func TestScanFindsHelperLeftovers(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	appPath := fs.createApp(t, "Test App", "com.test.app")
+	writeInfoPlist(t, filepath.Join(appPath, "Contents", "Library", "LoginItems", "Launcher.app"), "com.vendor.launcher")
+	appPrefs := fs.createPrefFile(t, "com.test.app", ".plist")
+	launcherPrefs := fs.createPrefFile(t, "com.vendor.launcher", ".plist")
+	launcherAgent := fs.createLaunchAgent(t, "com.vendor.launcher")
+	unrelated := fs.createPrefFile(t, "com.vendor.other", ".plist")
+
+	app := AppInfo{Name: "Test App", BundleID: "com.test.app", Path: appPath}
+	if err := scanAssociatedFiles(context.Background(), &app); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	helpers := map[string]string{}
+	for _, f := range app.Findings {
+		helpers[f.Path] = f.Helper
+	}
+	if h, ok := helpers[appPrefs]; !ok || h != "" {
+		t.Errorf("expected the app's own preferences without a helper, got %q (found %v)", h, ok)
+	}
+	for _, path := range []string{launcherPrefs, launcherAgent} {
+		if helpers[path] != "com.vendor.launcher" {
+			t.Errorf("expected %s to be attributed to the login item, got %q", path, helpers[path])
+		}
+	}
+	if _, ok := helpers[unrelated]; ok {
+		t.Error("expected other items from the helper's vendor not to match")
+	}
+}
+
+func writeInfoPlist(t *testing.T, bundle, bundleID string) {
+	t.Helper()
+	contents := filepath.Join(bundle, "Contents")
+	if err := os.MkdirAll(contents, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", contents, err)
+	}
+	data := encodeBinaryPlist(t, map[string]any{"CFBundleIdentifier": bundleID})
+	if err := os.WriteFile(filepath.Join(contents, "Info.plist"), data, 0644); err != nil {
+		t.Fatalf("failed to write Info.plist: %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// scanWorkers bounds how many directories are read or sized at once.
+const scanWorkers = 8
+
+// A libraryIndex lists every candidate directory once, so scanners query
+// memory rather than re-reading ~/Library for each app.
+type libraryIndex struct {
+	mu      sync.Mutex
+	entries map[string][]fs.DirEntry
+	plists  map[string]map[string]any
+}
+
+func newLibraryIndex() *libraryIndex {
+	return &libraryIndex{entries: map[string][]fs.DirEntry{}, plists: map[string]map[string]any{}}
+}
+
+// buildLibraryIndex reads the locations of every registered scanner, and
+// the launchd plists among them, concurrently.
+func buildLibraryIndex(ctx context.Context) (*libraryIndex, error) {
+	var dirs []string
+	for _, s := range registeredScanners() {
+		dirs = append(dirs, s.Locations()...)
+	}
+
+	listed := make([][]fs.DirEntry, len(dirs))
+	err := parallel(ctx, len(dirs), func(i int) {
+		listed[i], _ = os.ReadDir(dirs[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idx := newLibraryIndex()
+	for i, dir := range dirs {
+		idx.entries[dir] = listed[i]
+	}
+
+	var paths []string
+	for _, s := range registeredScanners() {
+		if _, ok := s.(*launchdScanner); !ok {
+			continue
+		}
+		for _, dir := range s.Locations() {
+			for _, entry := range idx.entries[dir] {
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	parsed := make([]map[string]any, len(paths))
+	err = parallel(ctx, len(paths), func(i int) {
+		parsed[i], _ = readPlistDict(paths[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, path := range paths {
+		idx.plists[path] = parsed[i]
+	}
+	return idx, nil
+}
+
+// list returns the entries of dir, reading it on first use if it was not
+// indexed up front. Missing directories have no entries. The lock only
+// guards the map, so a directory asked for twice at once may be read twice.
+func (idx *libraryIndex) list(dir string) []fs.DirEntry {
+	idx.mu.Lock()
+	entries, ok := idx.entries[dir]
+	idx.mu.Unlock()
+	if !ok {
+		entries, _ = os.ReadDir(dir)
+		idx.mu.Lock()
+		idx.entries[dir] = entries
+		idx.mu.Unlock()
+	}
+	return entries
+}
+
+// plist returns the parsed dictionary at path, reading it on first use if
+// it was not indexed up front. Unreadable plists are nil.
+func (idx *libraryIndex) plist(path string) map[string]any {
+	idx.mu.Lock()
+	dict, ok := idx.plists[path]
+	idx.mu.Unlock()
+	if !ok {
+		dict, _ = readPlistDict(path)
+		idx.mu.Lock()
+		idx.plists[path] = dict
+		idx.mu.Unlock()
+	}
+	return dict
+}
+
+// glob is filepath.Glob answered from the index: every directory the
+// pattern walks through is listed once and then shared by all apps.
+func (idx *libraryIndex) glob(pattern string) []string {
+	dir, file := filepath.Split(pattern)
+	dir = filepath.Clean(dir)
+	dirs := []string{dir}
+	if hasMeta(dir) {
+		dirs = idx.glob(dir)
+	}
+	var matches []string
+	for _, d := range dirs {
+		for _, entry := range idx.list(d) {
+			if ok, _ := filepath.Match(file, entry.Name()); ok {
+				matches = append(matches, filepath.Join(d, entry.Name()))
+			}
+		}
+	}
+	return matches
+}
+
+// parallel calls fn for 0..n-1 on up to scanWorkers goroutines and stops
+// handing out work once ctx is cancelled.
+func parallel(ctx context.Context, n int, fn func(i int)) error {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(scanWorkers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return ctx.Err()
+}
+
+// matchApp runs every registered scanner for app against idx.
+// Helpers inside the bundle are matched by their own bundle IDs, and
+// findings only they explain are attributed to them.
+func matchApp(idx *libraryIndex, app *AppInfo) []Finding {
+	findings := scanAll(idx, app, newMatcher(app, matchBundleIDFor(app)))
+
+	if app.Path != "" {
+		app.Helpers = findHelpers(app.Path, matchBundleIDFor(app))
+	}
+	for _, h := range app.Helpers {
+		helper := &AppInfo{BundleID: h.BundleID}
+		for _, f := range scanAll(idx, helper, newMatcher(helper, h.BundleID)) {
+			if f.Rule <= matchBundlePrefix {
+				f.Helper = h.BundleID
+				findings = append(findings, f)
+			}
+		}
+	}
+	return normalizeFindings(findings)
+}
+
+func scanAll(idx *libraryIndex, app *AppInfo, m *matcher) []Finding {
+	var findings []Finding
+	for _, s := range registeredScanners() {
+		findings = append(findings, s.Scan(idx, app, m)...)
+	}
+	return append(findings, knownFindings(idx, m)...)
+}
+
+// scanApps matches apps concurrently and then sizes their bundles and
+// findings.
+func scanApps(ctx context.Context, idx *libraryIndex, apps []*AppInfo) error {
+	err := parallel(ctx, len(apps), func(i int) {
+		apps[i].Findings = matchApp(idx, apps[i])
+	})
+	if err != nil {
+		return err
+	}
+	err = parallel(ctx, len(apps), func(i int) {
+		if apps[i].Path != "" {
+			apps[i].Size = diskUsage(apps[i].Path)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if err := describeFindings(ctx, app.Findings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interruptible runs scan with Ctrl-C cancelling it. Outside of scans
+// Ctrl-C keeps its default behaviour so prompts can still be left.
+func interruptible(scan func(ctx context.Context) error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := scan(ctx)
+	stop()
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "Interrupted.")
+		os.Exit(130)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBuildLibraryIndex(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	prefPath := fs.createPrefFile(t, "com.test.app", ".plist")
+	agent := filepath.Join(fs.homeDir, "Library", "LaunchAgents", "com.test.app.agent.plist")
+	if err := os.WriteFile(agent, encodeBinaryPlist(t, map[string]any{"Label": "com.test.app.agent"}), 0644); err != nil {
+		t.Fatalf("failed to write agent: %v", err)
+	}
+
+	idx, err := buildLibraryIndex(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Entries created after indexing are not seen, showing that scanners
+	// query the index instead of the disk.
+	fs.createPrefFile(t, "com.test.later", ".plist")
+
+	var names []string
+	for _, e := range idx.list(filepath.Dir(prefPath)) {
+		names = append(names, e.Name())
+	}
+	if len(names) != 1 || names[0] != "com.test.app.plist" {
+		t.Errorf("expected only the indexed preference file, got %v", names)
+	}
+
+	extra := filepath.Join(fs.rootDir, "extra")
+	if err := os.MkdirAll(filepath.Join(extra, "item"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if entries := idx.list(extra); len(entries) != 1 {
+		t.Errorf("expected directories outside the index to be read on demand, got %v", entries)
+	}
+	if entries := idx.list(filepath.Join(fs.rootDir, "missing")); entries != nil {
+		t.Errorf("expected no entries for a missing directory, got %v", entries)
+	}
+
+	// Launchd plists are parsed while indexing too.
+	if err := os.Remove(agent); err != nil {
+		t.Fatalf("failed to remove agent: %v", err)
+	}
+	if job := idx.plist(agent); plistString(job, "Label") != "com.test.app.agent" {
+		t.Errorf("expected the agent to be parsed up front, got %v", job)
+	}
+}
+
+func TestLibraryIndexGlob(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	logDir := fs.createAppSupportDir(t, "Test App")
+	for _, name := range []string{"one.log", "two.log", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(logDir, name), []byte("test"), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+	dotfile := filepath.Join(fs.homeDir, ".testapp")
+	if err := os.WriteFile(dotfile, []byte("test"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	idx, err := buildLibraryIndex(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for pattern, want := range map[string]int{
+		filepath.Join(logDir, "*.log"):                        2,
+		filepath.Join(filepath.Dir(logDir), "Test*", "*.txt"): 1,
+		dotfile:                               1,
+		filepath.Join(fs.homeDir, ".missing"): 0,
+	} {
+		got := idx.glob(pattern)
+		globbed, _ := filepath.Glob(pattern)
+		if len(got) != want || len(globbed) != want {
+			t.Errorf("glob(%q) = %v, filepath.Glob = %v; want %d matches", pattern, got, globbed, want)
+		}
+	}
+}
+
+func TestScanApps(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	var apps []*AppInfo
+	for _, id := range []string{"one", "two", "three"} {
+		bundleID := "com.test." + id
+		fs.createPrefFile(t, bundleID, ".plist")
+		apps = append(apps, &AppInfo{Name: id, BundleID: bundleID, Path: fs.createApp(t, id, bundleID)})
+	}
+
+	idx, err := buildLibraryIndex(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := scanApps(context.Background(), idx, apps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, app := range apps {
+		paths := findingPaths(*app, "associated-files")
+		if len(paths) != 1 || filepath.Base(paths[0]) != app.BundleID+".plist" {
+			t.Errorf("%s: unexpected findings %v", app.Name, paths)
+		}
+		if app.Size == 0 || app.Findings[0].Size == 0 {
+			t.Errorf("%s: expected the bundle and findings to be sized", app.Name)
+		}
+	}
+}
+
+func TestParallelCancel(t *testing.T) {
+	var calls atomic.Int32
+	if err := parallel(context.Background(), 100, func(int) { calls.Add(1) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 100 {
+		t.Errorf("expected 100 calls, got %d", calls.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls.Store(0)
+	err := parallel(ctx, 100, func(int) {
+		if calls.Add(1) == 1 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls.Load() == 100 {
+		t.Error("expected work to stop after cancellation")
+	}
+}
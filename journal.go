@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// A journalSession records everything removed by one uninstall so that
+// `zaap restore` can put it back.
+type journalSession struct {
+	ID      string         `json:"id"`
+	Started time.Time      `json:"started"`
+	Items   []journalEntry `json:"items"`
+
+	path string
+}
+
+type journalEntry struct {
+	App      string      `json:"app"`
+	Original string      `json:"original"`
+	Trashed  string      `json:"trashed,omitempty"`
+	Size     int64       `json:"size"`
+	Mode     os.FileMode `json:"mode"`
+	UID      int         `json:"uid"`
+	GID      int         `json:"gid"`
+	Restored bool        `json:"restored,omitempty"`
+}
+
+func journalDir() string {
+	return homePath("Library", "Application Support", "zaap", "journal")
+}
+
+func newJournalSession() (*journalSession, error) {
+	dir := journalDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	base := now.Format("20060102-150405")
+	for n := 1; ; n++ {
+		id := base
+		if n > 1 {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		path := filepath.Join(dir, id+".json")
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		f.Close()
+
+		j := &journalSession{ID: id, Started: now, Items: []journalEntry{}, path: path}
+		return j, j.save()
+	}
+}
+
+func loadJournalSession(id string) (*journalSession, error) {
+	path := filepath.Join(journalDir(), id+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no such session: %s", id)
+		}
+		return nil, err
+	}
+	var j journalSession
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("reading session %s: %w", id, err)
+	}
+	j.path = path
+	return &j, nil
+}
+
+// listJournalSessions returns all recorded sessions, newest first.
+func listJournalSessions() ([]*journalSession, error) {
+	entries, err := os.ReadDir(journalDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var sessions []*journalSession
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		j, err := loadJournalSession(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		sessions = append(sessions, j)
+	}
+	sort.Slice(sessions, func(i, k int) bool {
+		return sessions[i].Started.After(sessions[k].Started)
+	})
+	return sessions, nil
+}
+
+func (j *journalSession) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// remove deletes path and records it in the journal. A nil session removes
+// without recording.
+func (j *journalSession) remove(app, path string) error {
+	if j == nil {
+		return deletePath(path)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	entry := journalEntry{
+		App:      app,
+		Original: path,
+		Size:     diskUsage(path),
+		Mode:     info.Mode(),
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		entry.UID = int(st.Uid)
+		entry.GID = int(st.Gid)
+	}
+
+	dest, err := removePath(path)
+	if err != nil {
+		return err
+	}
+	entry.Trashed = dest
+	j.Items = append(j.Items, entry)
+	if err := j.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not update journal: %v\n", err)
+	}
+	return nil
+}
+
+func (j *journalSession) apps() []string {
+	var apps []string
+	seen := map[string]bool{}
+	for _, e := range j.Items {
+		if !seen[e.App] {
+			seen[e.App] = true
+			apps = append(apps, e.App)
+		}
+	}
+	return apps
+}
+
+var errRestoreConflict = errors.New("original path has been recreated")
+
+// restore moves a trashed item back to its original location and reapplies
+// its mode and ownership.
+func (j *journalSession) restore(i int) error {
+	e := &j.Items[i]
+	if e.Restored {
+		return fmt.Errorf("already restored")
+	}
+	if e.Trashed == "" {
+		return fmt.Errorf("deleted permanently, cannot restore")
+	}
+	if _, err := os.Lstat(e.Original); err == nil {
+		return errRestoreConflict
+	}
+	if _, err := os.Lstat(e.Trashed); err != nil {
+		return fmt.Errorf("no longer in the Trash: %s", e.Trashed)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.Original), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(e.Trashed, e.Original); err != nil {
+		return err
+	}
+	if e.Mode&os.ModeSymlink == 0 {
+		if err := os.Chmod(e.Original, e.Mode); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not restore mode of %s: %v\n", e.Original, err)
+		}
+	}
+	if err := os.Lchown(e.Original, e.UID, e.GID); err != nil && !os.IsPermission(err) {
+		fmt.Fprintf(os.Stderr, "Warning: could not restore ownership of %s: %v\n", e.Original, err)
+	}
+
+	e.Restored = true
+	return j.save()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalRemoveAndRestore(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	appPath := fs.createApp(t, "TestApp", "com.test.app")
+	prefPath := fs.createPrefFile(t, "com.test.app", ".plist")
+	if err := os.Chmod(prefPath, 0600); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+	prefUsage := diskUsage(prefPath)
+
+	journal, err := newJournalSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range []string{appPath, prefPath} {
+		if err := journal.remove("TestApp", p); err != nil {
+			t.Fatalf("failed to remove %s: %v", p, err)
+		}
+		if exists, _ := pathExists(p); exists {
+			t.Errorf("%s should have been removed", p)
+		}
+	}
+
+	loaded, err := loadJournalSession(journal.ID)
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if len(loaded.Items) != 2 {
+		t.Fatalf("expected 2 journal entries, got %d", len(loaded.Items))
+	}
+	pref := loaded.Items[1]
+	if pref.App != "TestApp" || pref.Original != prefPath || pref.Size != prefUsage || pref.Mode.Perm() != 0600 {
+		t.Errorf("unexpected journal entry: %+v", pref)
+	}
+	if pref.UID != os.Getuid() {
+		t.Errorf("expected uid %d, got %d", os.Getuid(), pref.UID)
+	}
+
+	for i := range loaded.Items {
+		if err := loaded.restore(i); err != nil {
+			t.Fatalf("failed to restore item %d: %v", i, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(appPath, "Contents", "Info.plist")); err != nil {
+		t.Errorf("app should have been restored: %v", err)
+	}
+	info, err := os.Stat(prefPath)
+	if err != nil {
+		t.Fatalf("pref should have been restored: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	if err := loaded.restore(0); err == nil {
+		t.Error("expected error restoring an item twice")
+	}
+
+	reloaded, err := loadJournalSession(journal.ID)
+	if err != nil {
+		t.Fatalf("failed to reload session: %v", err)
+	}
+	if !reloaded.Items[0].Restored || !reloaded.Items[1].Restored {
+		t.Error("restored items should be marked in the journal")
+	}
+}
+
+func TestJournalRestoreConflict(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	cachesPath := fs.createCachesDir(t, "com.test.app")
+
+	journal, err := newJournalSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := journal.remove("TestApp", cachesPath); err != nil {
+		t.Fatalf("failed to remove: %v", err)
+	}
+
+	fs.createCachesDir(t, "com.test.app")
+	if err := journal.restore(0); !errors.Is(err, errRestoreConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if exists, _ := pathExists(journal.Items[0].Trashed); !exists {
+		t.Error("conflicting item should remain in the Trash")
+	}
+}
+
+func TestJournalPermanentCannotRestore(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	permanent = true
+	defer func() { permanent = false }()
+
+	prefPath := fs.createPrefFile(t, "com.test.app", ".plist")
+	journal, err := newJournalSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := journal.remove("TestApp", prefPath); err != nil {
+		t.Fatalf("failed to remove: %v", err)
+	}
+	if journal.Items[0].Trashed != "" {
+		t.Errorf("permanent deletion should not record a trash location")
+	}
+	if err := journal.restore(0); err == nil {
+		t.Error("expected error restoring a permanently deleted item")
+	}
+}
+
+func TestListJournalSessionsAndSelect(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	first, err := newJournalSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := newJournalSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("session IDs should be unique, both %s", first.ID)
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		path := fs.createCachesDir(t, name)
+		if err := second.remove("TestApp", path); err != nil {
+			t.Fatalf("failed to remove: %v", err)
+		}
+	}
+
+	sessions, err := listJournalSessions()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	indexes, err := selectSessionItems(second, []string{"3", second.Items[0].Original})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(indexes) != 2 || indexes[0] != 2 || indexes[1] != 0 {
+		t.Errorf("unexpected selection: %v", indexes)
+	}
+
+	all, err := selectSessionItems(second, nil)
+	if err != nil || len(all) != 3 {
+		t.Errorf("expected all 3 items selected, got %v (%v)", all, err)
+	}
+
+	if _, err := selectSessionItems(second, []string{"4"}); err == nil {
+		t.Error("expected error for out of range item")
+	}
+	if _, err := selectSessionItems(second, []string{"/nope"}); err == nil {
+		t.Error("expected error for unknown path")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A launchctlRunner runs launchctl. Tests replace it so nothing is
+// actually unloaded.
+type launchctlRunner interface {
+	run(args ...string) ([]byte, error)
+}
+
+type execLaunchctl struct{}
+
+func (execLaunchctl) run(args ...string) ([]byte, error) {
+	return exec.Command("launchctl", args...).CombinedOutput()
+}
+
+var launchctl launchctlRunner = execLaunchctl{}
+
+// jobTarget returns the launchctl service target for the job defined by
+// the plist at path: system/<label> for daemons in /Library/LaunchDaemons
+// and gui/<uid>/<label> for agents.
+func jobTarget(path string) string {
+	label := ""
+	if job, err := readPlistDict(path); err == nil {
+		label = plistString(job, "Label")
+	}
+	if label == "" {
+		label = strings.TrimSuffix(filepath.Base(path), ".plist")
+	}
+
+	domain := "gui/" + strconv.Itoa(targetUID())
+	if isUnder(path, systemPath("/Library/LaunchDaemons")) {
+		domain = "system"
+	}
+	return domain + "/" + label
+}
+
+// unloadJob boots out the job defined by the plist at path if it is
+// loaded, returning the target it unloaded. Jobs on an offline volume
+// cannot be unloaded and are left alone.
+func unloadJob(path string) (string, error) {
+	if volumeRoot != "" {
+		return "", nil
+	}
+	target := jobTarget(path)
+	if _, err := launchctl.run("print", target); err != nil {
+		return "", nil
+	}
+	if out, err := launchctl.run("bootout", target); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			err = fmt.Errorf("%s", msg)
+		}
+		return "", fmt.Errorf("could not unload %s: %w", target, err)
+	}
+	return target, nil
+}
+
+// unloadedJobs maps the plists of jobs unloaded in this run to their
+// service targets.
+var unloadedJobs = map[string]string{}
+
+// unloadStartupItem unloads the job defined by the plist at path unless it
+// was already unloaded in this run, and returns its target either way.
+func unloadStartupItem(path string) (string, error) {
+	if target, ok := unloadedJobs[path]; ok {
+		return target, nil
+	}
+	target, err := unloadJob(path)
+	if err != nil || target == "" {
+		return "", err
+	}
+	unloadedJobs[path] = target
+	if !jsonOutput() {
+		fmt.Printf("Unloaded: %s\n", target)
+	}
+	return target, nil
+}
+
+// unloadStartupItems unloads the jobs of the chosen startup items, before running apps are stopped and bundles removed, so
+// launchd neither respawns a quit app nor keeps running a program from a
+// bundle that is gone. Jobs that fail to unload are reported again, and
+// their plists kept, when the items are deleted.
+func unloadStartupItems(chosen [][]Finding) {
+	if dryRun {
+		return
+	}
+	for _, findings := range chosen {
+		for _, f := range findings {
+			if f.Category != "startup-items" || f.kept() || checkRemovable(f.Path) != nil {
+				continue
+			}
+			if _, err := unloadStartupItem(f.Path); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+}
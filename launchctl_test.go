@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeLaunchctl records launchctl calls and treats the targets in loaded
+// as loaded jobs.
+type fakeLaunchctl struct {
+	loaded  map[string]bool
+	failing map[string]bool
+	calls   []string
+	// onBootout, if set, is called before each bootout.
+	onBootout func(target string)
+}
+
+func (f *fakeLaunchctl) run(args ...string) ([]byte, error) {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	target := args[len(args)-1]
+	switch args[0] {
+	case "print":
+		if !f.loaded[target] {
+			return []byte("Could not find service"), errors.New("exit status 113")
+		}
+	case "bootout":
+		if f.onBootout != nil {
+			f.onBootout(target)
+		}
+		if f.failing[target] {
+			return []byte("Boot-out failed: 1: Operation not permitted"), errors.New("exit status 1")
+		}
+		delete(f.loaded, target)
+	}
+	return nil, nil
+}
+
+func useFakeLaunchctl(t *testing.T, f *fakeLaunchctl) {
+	t.Helper()
+	saved := launchctl
+	launchctl = f
+	t.Cleanup(func() { launchctl = saved })
+}
+
+func TestJobTarget(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	agent := filepath.Join(fs.homeDir, "Library", "LaunchAgents", "com.test.agent.plist")
+	if err := os.WriteFile(agent, encodeBinaryPlist(t, map[string]any{"Label": "com.test.real"}), 0644); err != nil {
+		t.Fatalf("failed to write agent: %v", err)
+	}
+	uid := strconv.Itoa(os.Getuid())
+
+	tests := map[string]string{
+		agent: "gui/" + uid + "/com.test.real",
+		filepath.Join(fs.homeDir, "Library", "LaunchAgents", "com.test.other.plist"): "gui/" + uid + "/com.test.other",
+		"/Library/LaunchDaemons/com.test.daemon.plist":                               "system/com.test.daemon",
+	}
+	for path, want := range tests {
+		if got := jobTarget(path); got != want {
+			t.Errorf("jobTarget(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRemoveStartupItemUnloadsJob(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+	uid := strconv.Itoa(os.Getuid())
+
+	loaded := fs.createLaunchAgent(t, "com.test.loaded")
+	idle := fs.createLaunchAgent(t, "com.test.idle")
+	stuck := fs.createLaunchAgent(t, "com.test.stuck")
+	defer func() { unloadedJobs = map[string]string{} }()
+	fake := &fakeLaunchctl{
+		loaded:  map[string]bool{"gui/" + uid + "/com.test.loaded": true, "gui/" + uid + "/com.test.stuck": true},
+		failing: map[string]bool{"gui/" + uid + "/com.test.stuck": true},
+	}
+	useFakeLaunchctl(t, fake)
+
+	result := removeAndReport(nil, "Test", "startup-items", loaded)
+	if result.Unloaded != "gui/"+uid+"/com.test.loaded" || result.Error != "" {
+		t.Errorf("expected the loaded job to be unloaded and removed, got %+v", result)
+	}
+	if result := removeAndReport(nil, "Test", "startup-items", idle); result.Unloaded != "" || result.Error != "" {
+		t.Errorf("expected the idle job to be removed without unloading, got %+v", result)
+	}
+
+	result = removeAndReport(nil, "Test", "startup-items", stuck)
+	if result.Action != "failed" || !strings.Contains(result.Error, "could not unload") {
+		t.Errorf("expected a job that cannot be unloaded to fail, got %+v", result)
+	}
+	if _, err := os.Stat(stuck); err != nil {
+		t.Error("expected the plist of a job that could not be unloaded to be kept")
+	}
+
+	fake.calls = nil
+	dryRun = true
+	defer func() { dryRun = false }()
+	removeAndReport(nil, "Test", "startup-items", stuck)
+	if len(fake.calls) > 0 {
+		t.Errorf("expected no launchctl calls in a dry run, got %v", fake.calls)
+	}
+}
+
+func TestDeleteAppsUnloadsJobsBeforeRemovingBundle(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+	outputFormat, assumeYes = "json", true
+	defer func() { outputFormat, assumeYes = "text", false }()
+	defer func() { unloadedJobs = map[string]string{} }()
+
+	appPath := fs.createApp(t, "Test App", "com.test.app")
+	agent := filepath.Join(fs.homeDir, "Library", "LaunchAgents", "com.vendor.updater.plist")
+	job := map[string]any{"Label": "com.vendor.updater", "Program": filepath.Join(appPath, "Contents", "MacOS", "updater")}
+	if err := os.WriteFile(agent, encodeBinaryPlist(t, job), 0644); err != nil {
+		t.Fatalf("failed to write agent: %v", err)
+	}
+
+	target := "gui/" + strconv.Itoa(os.Getuid()) + "/com.vendor.updater"
+	bundleThere := false
+	fake := &fakeLaunchctl{loaded: map[string]bool{target: true}}
+	fake.onBootout = func(string) {
+		bundleThere, _ = pathExists(appPath)
+	}
+	useFakeLaunchctl(t, fake)
+	savedProcesses := processes
+	processes = &fakeProcesses{}
+	defer func() { processes = savedProcesses }()
+
+	targets, installed := resolveTargets([]string{"Test App"}, nil)
+	deleteApps(targets, installed)
+
+	if !bundleThere {
+		t.Error("expected the job to be unloaded while the app bundle still existed")
+	}
+	if exists, _ := pathExists(agent); exists {
+		t.Error("expected the agent plist to be removed")
+	}
+	bootouts := 0
+	for _, c := range fake.calls {
+		if strings.HasPrefix(c, "bootout") {
+			bootouts++
+		}
+	}
+	if bootouts != 1 {
+		t.Errorf("expected one bootout, got %v", fake.calls)
+	}
+}
+
+func TestPrepareTargetsAbortLeavesJobsLoaded(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+	defer func() { unloadedJobs = map[string]string{} }()
+
+	appPath := fs.createApp(t, "Test App", "com.test.app")
+	agent := filepath.Join(fs.homeDir, "Library", "LaunchAgents", "com.test.app.agent.plist")
+	job := map[string]any{"Label": "com.test.app.agent", "Program": filepath.Join(appPath, "Contents", "MacOS", "agent")}
+	if err := os.WriteFile(agent, encodeBinaryPlist(t, job), 0644); err != nil {
+		t.Fatalf("failed to write agent: %v", err)
+	}
+
+	target := "gui/" + strconv.Itoa(os.Getuid()) + "/com.test.app.agent"
+	fake := &fakeLaunchctl{loaded: map[string]bool{target: true}}
+	useFakeLaunchctl(t, fake)
+	savedProcesses := processes
+	procs := &fakeProcesses{procs: []process{{pid: 412, command: filepath.Join(appPath, "Contents", "MacOS", "Test App")}}}
+	processes = procs
+	ifRunning = "abort"
+	defer func() { processes, ifRunning = savedProcesses, "" }()
+
+	targets, installed := resolveTargets([]string{"Test App"}, nil)
+	scanTargets(targets, installed)
+	chosen := chooseFindings(targets, nil)
+	if !slices.ContainsFunc(chosen[0], func(f Finding) bool { return f.Path == agent }) {
+		t.Fatalf("expected the agent to be chosen, got %+v", chosen[0])
+	}
+
+	if err := prepareTargets(targets, chosen, nil); err == nil {
+		t.Fatal("expected an error for a running app with --running abort")
+	}
+	for _, c := range fake.calls {
+		if strings.HasPrefix(c, "bootout") {
+			t.Errorf("expected no bootout when aborting, got %v", fake.calls)
+		}
+	}
+	if len(procs.calls) != 0 {
+		t.Errorf("expected nothing stopped, got %v", procs.calls)
+	}
+}
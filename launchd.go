@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// launchdScanner matches launch agents and daemons by what their plist
+// runs, falling back to the file name for plists it cannot read.
+type launchdScanner struct {
+	dirScanner
+}
+
+func (s *launchdScanner) Scan(idx *libraryIndex, app *AppInfo, m *matcher) []Finding {
+	var found []Finding
+	for _, loc := range s.locations {
+		dir := loc.path()
+		for _, entry := range idx.list(dir) {
+			path := filepath.Join(dir, entry.Name())
+			if rule, key, ok := matchJob(idx.plist(path), entry.Name(), app, m, loc.rules); ok {
+				f := newFinding(path, s.id, rule)
+				f.Key = key
+				found = append(found, f)
+			}
+		}
+	}
+	return found
+}
+
+// matchJob decides whether the launchd job in file name belongs to app and
+// returns the rule and the plist key that matched. A job running a program
+// from another app's bundle is never matched by its name.
+func matchJob(job map[string]any, name string, app *AppInfo, m *matcher, rules []matchRule) (matchRule, string, bool) {
+	if job == nil {
+		rule, ok := m.match(name, rules)
+		return rule, "", ok
+	}
+
+	ids := plistStrings(job, "AssociatedBundleIdentifiers")
+	if id := plistString(job, "AssociatedBundleIdentifiers"); id != "" {
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		if slices.Contains(m.bundleIDs, strings.ToLower(id)) {
+			return matchBundleID, "AssociatedBundleIdentifiers", true
+		}
+	}
+
+	programs := []struct{ key, path string }{
+		{"Program", plistString(job, "Program")},
+		{"ProgramArguments", firstString(plistStrings(job, "ProgramArguments"))},
+		{"BundleProgram", plistString(job, "BundleProgram")},
+	}
+	foreign := false
+	for _, p := range programs {
+		if p.path == "" {
+			continue
+		}
+		if !filepath.IsAbs(p.path) {
+			// BundleProgram is relative to the bundle that registered the job.
+			if p.key == "BundleProgram" && app.Path != "" {
+				if _, err := os.Stat(filepath.Join(app.Path, p.path)); err == nil {
+					return matchAppPath, p.key, true
+				}
+			}
+			continue
+		}
+		path := systemPath(p.path)
+		if app.Path != "" && isUnder(path, app.Path) {
+			return matchAppPath, p.key, true
+		}
+		if bundle := enclosingApp(path); bundle != "" {
+			if _, err := os.Stat(bundle); err == nil {
+				foreign = true
+			}
+		}
+	}
+	if foreign {
+		return 0, "", false
+	}
+
+	rule, ok := m.match(name, rules)
+	if label := plistString(job, "Label"); label != "" {
+		if labelRule, labelOK := m.match(label, rules); labelOK && (!ok || labelRule < rule) {
+			return labelRule, "Label", true
+		}
+	}
+	return rule, "", ok
+}
+
+// enclosingApp returns the outermost .app bundle path lies in, if any.
+func enclosingApp(path string) string {
+	parts := strings.Split(path, string(filepath.Separator))
+	for i, part := range parts {
+		if strings.HasSuffix(part, ".app") {
+			return strings.Join(parts[:i+1], string(filepath.Separator))
+		}
+	}
+	return ""
+}
+
+func firstString(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
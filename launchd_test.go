@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLaunchdScanner(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	appPath := fs.createApp(t, "Test App", "com.test.app")
+	otherPath := fs.createApp(t, "Other", "com.other.app")
+	helper := filepath.Join(appPath, "Contents", "Library", "LaunchServices", "helper")
+	if err := os.MkdirAll(filepath.Dir(helper), 0755); err != nil {
+		t.Fatalf("failed to create helper dir: %v", err)
+	}
+	if err := os.WriteFile(helper, []byte("test"), 0755); err != nil {
+		t.Fatalf("failed to create helper: %v", err)
+	}
+
+	agentsDir := filepath.Join(fs.homeDir, "Library", "LaunchAgents")
+	jobs := map[string]map[string]any{
+		"com.vendor.updater.plist": {
+			"Label":            "com.vendor.updater",
+			"ProgramArguments": []any{filepath.Join(appPath, "Contents", "MacOS", "updater"), "--daemon"},
+		},
+		"com.test.app.sync.plist": {
+			"Label":   "com.test.app.sync",
+			"Program": filepath.Join(otherPath, "Contents", "MacOS", "sync"),
+		},
+		"com.vendor.login.plist": {
+			"Label":                       "com.vendor.login",
+			"AssociatedBundleIdentifiers": []any{"com.test.app"},
+		},
+		"agent.plist": {
+			"Label":   "com.test.app.agent",
+			"Program": "/usr/local/bin/agent",
+		},
+		"com.vendor.service.plist": {
+			"Label":         "com.vendor.service",
+			"BundleProgram": "Contents/Library/LaunchServices/helper",
+		},
+		"com.unrelated.plist": {
+			"Label":   "com.unrelated",
+			"Program": "/usr/local/bin/unrelated",
+		},
+	}
+	for name, job := range jobs {
+		if err := os.WriteFile(filepath.Join(agentsDir, name), encodeBinaryPlist(t, job), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	app := AppInfo{Name: "Test App", BundleID: "com.test.app", Path: appPath}
+	findings := scannerByID("startup-items").Scan(newLibraryIndex(), &app, newMatcher(&app, app.BundleID))
+
+	want := map[string]struct {
+		rule matchRule
+		key  string
+	}{
+		"com.vendor.updater.plist": {matchAppPath, "ProgramArguments"},
+		"com.vendor.login.plist":   {matchBundleID, "AssociatedBundleIdentifiers"},
+		"agent.plist":              {matchBundlePrefix, "Label"},
+		"com.vendor.service.plist": {matchAppPath, "BundleProgram"},
+	}
+	for _, f := range findings {
+		name := filepath.Base(f.Path)
+		w, ok := want[name]
+		if !ok {
+			t.Errorf("unexpected finding %s", name)
+			continue
+		}
+		if f.Rule != w.rule || f.Key != w.key {
+			t.Errorf("%s: got rule %s key %q, want %s %q", name, f.Rule, f.Key, w.rule, w.key)
+		}
+		delete(want, name)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing findings: %v", want)
+	}
+}
+
+func TestEnclosingApp(t *testing.T) {
+	tests := map[string]string{
+		"/Applications/Foo.app/Contents/MacOS/foo":                        "/Applications/Foo.app",
+		"/Applications/Foo.app/Contents/Helpers/Bar.app/Contents/MacOS/b": "/Applications/Foo.app",
+		"/usr/local/bin/foo": "",
+	}
+	for path, want := range tests {
+		if got := enclosingApp(path); got != want {
+			t.Errorf("enclosingApp(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A codeSignature holds what zaap uses from an executable's signature.
+type codeSignature struct {
+	Identifier   string
+	TeamID       string
+	Entitlements map[string]any
+}
+
+// appGroups returns the com.apple.security.application-groups entitlement.
+func (s codeSignature) appGroups() []string {
+	return plistStrings(s.Entitlements, "com.apple.security.application-groups")
+}
+
+// A machOImage is one architecture of an executable together with where
+// it starts in the file, since offsets in its load commands are relative
+// to that.
+type machOImage struct {
+	*macho.File
+	r      io.ReaderAt
+	offset int64
+}
+
+// openMachO opens the executable at path. For a universal binary it
+// returns the first architecture, which carries the same Info.plist and
+// signing identity as the others.
+func openMachO(path string) (machOImage, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return machOImage{}, nil, err
+	}
+	if fat, err := macho.NewFatFile(f); err == nil {
+		if len(fat.Arches) == 0 {
+			f.Close()
+			return machOImage{}, nil, fmt.Errorf("%s: empty universal binary", path)
+		}
+		arch := fat.Arches[0]
+		return machOImage{arch.File, f, int64(arch.Offset)}, f, nil
+	} else if !errors.Is(err, macho.ErrNotFat) {
+		f.Close()
+		return machOImage{}, nil, err
+	}
+	m, err := macho.NewFile(f)
+	if err != nil {
+		f.Close()
+		return machOImage{}, nil, err
+	}
+	return machOImage{m, f, 0}, f, nil
+}
+
+// embeddedInfoPlist reads the Info.plist that command-line tools such as
+// privileged helpers carry in their __TEXT,__info_plist section.
+func embeddedInfoPlist(path string) (map[string]any, error) {
+	img, closer, err := openMachO(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	sect := img.Section("__info_plist")
+	if sect == nil {
+		return nil, fmt.Errorf("%s: no embedded Info.plist", path)
+	}
+	data, err := sect.Data()
+	if err != nil {
+		return nil, err
+	}
+	return parsePlistDict(path, data)
+}
+
+func parsePlistDict(path string, data []byte) (map[string]any, error) {
+	v, err := parsePlist(data)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: embedded plist is not a dictionary", path)
+	}
+	return dict, nil
+}
+
+const (
+	loadCmdCodeSignature = 0x1d
+
+	csMagicEmbeddedSignature = 0xfade0cc0
+	csMagicCodeDirectory     = 0xfade0c02
+	csMagicEntitlements      = 0xfade7171
+
+	csSlotCodeDirectory = 0
+	csSlotEntitlements  = 5
+
+	// csSupportsTeamID is the first CodeDirectory version with a team ID.
+	csSupportsTeamID = 0x20200
+
+	// maxSignatureSize guards against corrupt load commands; real
+	// signatures are a few hundred kilobytes at most.
+	maxSignatureSize = 16 << 20
+)
+
+// readCodeSignature parses the embedded signature of the executable at
+// path: the signing identifier and team ID from its CodeDirectory and the
+// entitlements plist. Unsigned executables have an empty signature.
+func readCodeSignature(path string) (codeSignature, error) {
+	img, closer, err := openMachO(path)
+	if err != nil {
+		return codeSignature{}, err
+	}
+	defer closer.Close()
+
+	var sig codeSignature
+	for _, l := range img.Loads {
+		raw := l.Raw()
+		if len(raw) < 16 || img.ByteOrder.Uint32(raw) != loadCmdCodeSignature {
+			continue
+		}
+		off := int64(img.ByteOrder.Uint32(raw[8:]))
+		size := img.ByteOrder.Uint32(raw[12:])
+		if size > maxSignatureSize {
+			return codeSignature{}, fmt.Errorf("%s: signature of %d bytes is too large", path, size)
+		}
+		blob := make([]byte, size)
+		if _, err := img.r.ReadAt(blob, img.offset+off); err != nil {
+			return codeSignature{}, fmt.Errorf("%s: reading signature: %w", path, err)
+		}
+		if err := sig.parseSuperBlob(path, blob); err != nil {
+			return codeSignature{}, err
+		}
+	}
+	return sig, nil
+}
+
+// parseSuperBlob reads the blobs of an embedded signature. Unlike the rest
+// of the Mach-O file, signatures are always big-endian.
+func (s *codeSignature) parseSuperBlob(path string, blob []byte) error {
+	be := binary.BigEndian
+	if len(blob) < 12 || be.Uint32(blob) != csMagicEmbeddedSignature {
+		return fmt.Errorf("%s: not an embedded signature", path)
+	}
+	count := int(be.Uint32(blob[8:]))
+	if 12+count*8 > len(blob) {
+		return fmt.Errorf("%s: truncated signature", path)
+	}
+	for i := 0; i < count; i++ {
+		slot := be.Uint32(blob[12+i*8:])
+		off := int(be.Uint32(blob[16+i*8:]))
+		if off+8 > len(blob) {
+			return fmt.Errorf("%s: truncated signature", path)
+		}
+		length := int(be.Uint32(blob[off+4:]))
+		if length < 8 || off+length > len(blob) {
+			return fmt.Errorf("%s: truncated signature", path)
+		}
+		b := blob[off : off+length]
+
+		switch {
+		case slot == csSlotCodeDirectory && be.Uint32(b) == csMagicCodeDirectory:
+			s.parseCodeDirectory(b)
+		case slot == csSlotEntitlements && be.Uint32(b) == csMagicEntitlements:
+			ents, err := parsePlistDict(path, b[8:])
+			if err != nil {
+				return err
+			}
+			s.Entitlements = ents
+		}
+	}
+	return nil
+}
+
+func (s *codeSignature) parseCodeDirectory(cd []byte) {
+	be := binary.BigEndian
+	if len(cd) < 24 {
+		return
+	}
+	s.Identifier = cString(cd, be.Uint32(cd[20:]))
+	if be.Uint32(cd[8:]) >= csSupportsTeamID && len(cd) >= 52 {
+		if off := be.Uint32(cd[48:]); off != 0 {
+			s.TeamID = cString(cd, off)
+		}
+	}
+}
+
+func cString(b []byte, off uint32) string {
+	if int(off) >= len(b) {
+		return ""
+	}
+	s := b[off:]
+	if i := bytes.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return string(s)
+}
+
+// loadSignature fills in app's signing details from its main executable.
+// Apps that are unsigned or have no readable executable keep them empty.
+func loadSignature(app *AppInfo) {
+	app.SigningID, app.TeamID, app.AppGroups = "", "", nil
+	if app.Executable == "" {
+		return
+	}
+	sig, err := readCodeSignature(filepath.Join(app.Path, "Contents", "MacOS", app.Executable))
+	if err != nil {
+		return
+	}
+	app.SigningID = sig.Identifier
+	app.TeamID = strings.TrimSpace(sig.TeamID)
+	app.AppGroups = sig.appGroups()
+}
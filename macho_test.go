@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const (
+	testCPUAMD64 = 0x01000007
+	testCPUARM64 = 0x0100000c
+)
+
+// buildSignature builds an embedded signature superblob with a
+// CodeDirectory and, if entitlements is set, an entitlements blob.
+func buildSignature(ident, teamID, entitlements string) []byte {
+	be := binary.BigEndian
+
+	cd := make([]byte, 52)
+	be.PutUint32(cd[0:], csMagicCodeDirectory)
+	be.PutUint32(cd[8:], 0x20400)
+	be.PutUint32(cd[20:], uint32(len(cd)))
+	cd = append(append(cd, ident...), 0)
+	if teamID != "" {
+		be.PutUint32(cd[48:], uint32(len(cd)))
+		cd = append(append(cd, teamID...), 0)
+	}
+	be.PutUint32(cd[4:], uint32(len(cd)))
+
+	blobs := [][]byte{cd}
+	slots := []uint32{csSlotCodeDirectory}
+	if entitlements != "" {
+		ent := make([]byte, 8)
+		be.PutUint32(ent[0:], csMagicEntitlements)
+		be.PutUint32(ent[4:], uint32(8+len(entitlements)))
+		blobs = append(blobs, append(ent, entitlements...))
+		slots = append(slots, csSlotEntitlements)
+	}
+
+	header := make([]byte, 12+8*len(blobs))
+	be.PutUint32(header[0:], csMagicEmbeddedSignature)
+	be.PutUint32(header[8:], uint32(len(blobs)))
+	off := len(header)
+	for i, b := range blobs {
+		be.PutUint32(header[12+i*8:], slots[i])
+		be.PutUint32(header[16+i*8:], uint32(off))
+		off += len(b)
+	}
+	out := append(header, bytes.Join(blobs, nil)...)
+	be.PutUint32(out[4:], uint32(len(out)))
+	return out
+}
+
+// buildMachO builds a minimal 64-bit little-endian executable with an
+// optional __TEXT,__info_plist section and code signature.
+func buildMachO(cpu uint32, infoPlist, signature []byte) []byte {
+	le := binary.LittleEndian
+	var cmds bytes.Buffer
+	ncmds := 0
+	dataStart := 32 + 72 + 80 + 16
+
+	var data bytes.Buffer
+	if infoPlist != nil {
+		seg := make([]byte, 72)
+		le.PutUint32(seg[0:], 0x19)
+		le.PutUint32(seg[4:], 72+80)
+		copy(seg[8:], "__TEXT")
+		le.PutUint64(seg[40:], uint64(dataStart))
+		le.PutUint64(seg[48:], uint64(len(infoPlist)))
+		le.PutUint32(seg[64:], 1)
+		sect := make([]byte, 80)
+		copy(sect[0:], "__info_plist")
+		copy(sect[16:], "__TEXT")
+		le.PutUint64(sect[40:], uint64(len(infoPlist)))
+		le.PutUint32(sect[48:], uint32(dataStart))
+		cmds.Write(seg)
+		cmds.Write(sect)
+		data.Write(infoPlist)
+		ncmds++
+	}
+	if signature != nil {
+		cs := make([]byte, 16)
+		le.PutUint32(cs[0:], loadCmdCodeSignature)
+		le.PutUint32(cs[4:], 16)
+		le.PutUint32(cs[8:], uint32(dataStart+data.Len()))
+		le.PutUint32(cs[12:], uint32(len(signature)))
+		cmds.Write(cs)
+		data.Write(signature)
+		ncmds++
+	}
+
+	header := make([]byte, 32)
+	le.PutUint32(header[0:], 0xfeedfacf)
+	le.PutUint32(header[4:], cpu)
+	le.PutUint32(header[12:], 2)
+	le.PutUint32(header[16:], uint32(ncmds))
+	le.PutUint32(header[20:], uint32(cmds.Len()))
+
+	out := append(header, cmds.Bytes()...)
+	out = append(out, make([]byte, dataStart-len(out))...)
+	return append(out, data.Bytes()...)
+}
+
+// buildFat wraps thin executables in a universal binary.
+func buildFat(thin map[uint32][]byte, order ...uint32) []byte {
+	be := binary.BigEndian
+	const align = 0x1000
+	header := make([]byte, 8+20*len(order))
+	be.PutUint32(header[0:], 0xcafebabe)
+	be.PutUint32(header[4:], uint32(len(order)))
+	out := make([]byte, align)
+	for i, cpu := range order {
+		arch := header[8+20*i:]
+		be.PutUint32(arch[0:], cpu)
+		be.PutUint32(arch[8:], uint32(len(out)))
+		be.PutUint32(arch[12:], uint32(len(thin[cpu])))
+		be.PutUint32(arch[16:], 12)
+		out = append(out, thin[cpu]...)
+		out = append(out, make([]byte, align-len(thin[cpu])%align)...)
+	}
+	copy(out, header)
+	return out
+}
+
+const testEntitlements = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>com.apple.security.application-groups</key>
+	<array>
+		<string>ABCDE12345.com.test.shared</string>
+		<string>group.com.test</string>
+	</array>
+	<key>com.apple.security.app-sandbox</key>
+	<true/>
+</dict>
+</plist>`
+
+func TestReadCodeSignature(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"thin":     buildMachO(testCPUARM64, nil, buildSignature("com.test.app", "ABCDE12345", testEntitlements)),
+		"unsigned": buildMachO(testCPUARM64, nil, nil),
+		"fat": buildFat(map[uint32][]byte{
+			testCPUAMD64: buildMachO(testCPUAMD64, nil, buildSignature("com.test.app", "ABCDE12345", testEntitlements)),
+			testCPUARM64: buildMachO(testCPUARM64, nil, buildSignature("com.other", "ZZZZZ99999", "")),
+		}, testCPUAMD64, testCPUARM64),
+		"not macho": []byte("#!/bin/sh\n"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0755); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"thin", "fat"} {
+		sig, err := readCodeSignature(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if sig.Identifier != "com.test.app" || sig.TeamID != "ABCDE12345" {
+			t.Errorf("%s: unexpected identity %q %q", name, sig.Identifier, sig.TeamID)
+		}
+		want := []string{"ABCDE12345.com.test.shared", "group.com.test"}
+		if got := sig.appGroups(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected app groups %v, got %v", name, want, got)
+		}
+	}
+
+	if sig, err := readCodeSignature(filepath.Join(dir, "unsigned")); err != nil || sig.Identifier != "" {
+		t.Errorf("expected an empty signature for an unsigned binary, got %+v, %v", sig, err)
+	}
+	if _, err := readCodeSignature(filepath.Join(dir, "not macho")); err == nil {
+		t.Error("expected an error for a file that is not Mach-O")
+	}
+
+	truncated := buildSignature("com.test.app", "ABCDE12345", "")
+	var sig codeSignature
+	if err := sig.parseSuperBlob("truncated", truncated[:20]); err == nil {
+		t.Error("expected an error for a truncated signature")
+	}
+}
+
+func TestEmbeddedInfoPlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "com.test.privileged")
+	info := encodeBinaryPlist(t, map[string]any{"CFBundleIdentifier": "com.test.helper"})
+	if err := os.WriteFile(path, buildMachO(testCPUARM64, info, nil), 0755); err != nil {
+		t.Fatalf("failed to write helper: %v", err)
+	}
+	if got := helperBundleID(path); got != "com.test.helper" {
+		t.Errorf("expected the embedded bundle ID, got %q", got)
+	}
+}
+
+func TestScanMatchesGroupContainers(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	appPath := fs.createApp(t, "Test App", "com.test.app")
+	exe := filepath.Join(appPath, "Contents", "MacOS", "Test App")
+	if err := os.MkdirAll(filepath.Dir(exe), 0755); err != nil {
+		t.Fatalf("failed to create MacOS dir: %v", err)
+	}
+	if err := os.WriteFile(exe, buildMachO(testCPUARM64, nil, buildSignature("com.test.app", "ABCDE12345", testEntitlements)), 0755); err != nil {
+		t.Fatalf("failed to write executable: %v", err)
+	}
+
+	groups := filepath.Join(fs.homeDir, "Library", "Group Containers")
+	want := map[string]matchRule{
+		"group.com.test":             matchAppGroup,
+		"ABCDE12345.com.test.shared": matchAppGroup,
+		"ABCDE12345.com.test.other":  matchTeamID,
+	}
+	for _, name := range []string{"group.com.test", "ABCDE12345.com.test.shared", "ABCDE12345.com.test.other", "ZZZZZ99999.com.other"} {
+		if err := os.MkdirAll(filepath.Join(groups, name), 0755); err != nil {
+			t.Fatalf("failed to create group container: %v", err)
+		}
+	}
+
+	app := AppInfo{Path: appPath}
+	if err := loadBundleInfo(&app); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	app.Name = "Test App"
+	if app.TeamID != "ABCDE12345" || app.SigningID != "com.test.app" {
+		t.Fatalf("expected the signature to be loaded, got %q %q", app.TeamID, app.SigningID)
+	}
+	if err := scanAssociatedFiles(context.Background(), &app); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, f := range app.Findings {
+		if filepath.Dir(f.Path) != groups {
+			continue
+		}
+		name := filepath.Base(f.Path)
+		rule, ok := want[name]
+		if !ok {
+			t.Errorf("unexpected group container %s", name)
+			continue
+		}
+		if f.Rule != rule {
+			t.Errorf("%s: expected rule %s, got %s", name, rule, f.Rule)
+		}
+		delete(want, name)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing group containers: %v", want)
+	}
+}
@@ -2,33 +2,126 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	verbose    bool
-	listOnly   bool
-	deleteName string
-	dryRun     bool
+	verbose     bool
+	listOnly    bool
+	deleteNames []string
+	fromFile    string
+	scanName    string
+	dryRun      bool
+	permanent   bool
+
+	includeShared  bool
+	forceProtected bool
+	ifRunning      string
+	assumeYes      bool
+
+	outputFormat string
+	appDirs      []string
+	volumeRoot   string
+	targetUser   string
+
+	sortOrder string
+
+	restoreShow bool
+	orphansList bool
+	rmBundleIDs []string
 )
 
 type AppInfo struct {
-	Name            string
-	Path            string
-	BundleID        string
-	AssociatedFiles []string
-	ControlPanels   []string
-	StartupItems    []string
-	QuickLook       []string
-	ScreenSavers    []string
-	InputMethods    []string
-	Fonts           []string
+	Name                 string
+	Path                 string
+	Root                 string
+	BundleID             string
+	Size                 int64
+	BundleName           string
+	DisplayName          string
+	Version              string
+	BuildVersion         string
+	Executable           string
+	MinimumSystemVersion string
+	FeedURL              string
+	DocumentTypes        []DocumentType
+	URLTypes             []URLType
+	Helpers              []helperBundle
+	SigningID            string
+	TeamID               string
+	AppGroups            []string
+
+	Findings []Finding
+}
+
+const categoryApplication = "application"
+
+type category struct {
+	Key   string
+	Title string
+	Risk  riskLevel
+	Items []Finding
+}
+
+func (c category) size() int64 {
+	var total int64
+	for _, f := range c.Items {
+		total += f.Size
+	}
+	return total
+}
+
+// totalReclaimable adds up reclaimable space across apps, counting items
+// that several of them match once.
+func totalReclaimable(apps []AppInfo) int64 {
+	var total int64
+	seen := map[string]bool{}
+	for _, app := range apps {
+		total += app.Size
+		for _, f := range app.Findings {
+			if !f.kept() && !seen[f.Path] {
+				seen[f.Path] = true
+				total += f.Size
+			}
+		}
+	}
+	return total
+}
+
+// reclaimable is how much deleting app and its findings frees, leaving out
+// items that are kept because other apps use them.
+func (app *AppInfo) reclaimable() int64 {
+	total := app.Size
+	for _, f := range app.Findings {
+		if !f.kept() {
+			total += f.Size
+		}
+	}
+	return total
+}
+
+// categories returns the findings of every registered scanner, in
+// registration order.
+func (app *AppInfo) categories() []category {
+	var cats []category
+	for _, s := range registeredScanners() {
+		c := category{Key: s.ID(), Title: s.Name(), Risk: s.Risk()}
+		for _, f := range app.Findings {
+			if f.Category == c.Key {
+				c.Items = append(c.Items, f)
+			}
+		}
+		cats = append(cats, c)
+	}
+	return cats
 }
 
 func main() {
@@ -36,12 +129,79 @@ func main() {
 		Use:   "zaap",
 		Short: "macOS application cleanup utility",
 		Run:   run,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if err := setupConfig(cmd); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := checkTarget(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := checkRunningAction(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := checkOutputFormat(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
 	}
 
-	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.Flags().BoolVarP(&listOnly, "list", "l", false, "list applications only")
-	rootCmd.Flags().StringVarP(&deleteName, "delete", "d", "", "delete specific app by name")
-	rootCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "show what would be deleted without actually deleting")
+	rootCmd.Flags().StringArrayVarP(&deleteNames, "delete", "d", nil, "delete an app by name, path or bundle ID (repeatable)")
+	rootCmd.Flags().StringVar(&fromFile, "from-file", "", "also delete the apps listed in this file, one per line (- for stdin)")
+	rootCmd.Flags().StringVar(&sortOrder, "sort", "name", "order of --list: name or size")
+	rootCmd.Flags().StringVarP(&scanName, "scan", "s", "", "show items associated with an app (name or path) without deleting")
+	rootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "n", false, "show what would be deleted without actually deleting")
+	rootCmd.Flags().BoolVar(&permanent, "permanent", false, "delete permanently instead of moving to the Trash")
+	rootCmd.Flags().BoolVar(&includeShared, "include-shared", false, "also delete items that other installed apps appear to use")
+	rootCmd.Flags().BoolVar(&forceProtected, "force-protected", false, "allow deleting Apple, system and other protected apps and items")
+	rootCmd.Flags().StringVar(&ifRunning, "running", "", "what to do with running apps: abort, quit or kill (default: ask, or abort without a prompt)")
+	rootCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "delete without asking for confirmation (required with --output json)")
+	rootCmd.PersistentFlags().StringSliceVar(&appDirs, "apps-dir", nil, "application folder to search (repeatable; default /Applications and ~/Applications)")
+	rootCmd.PersistentFlags().StringVar(&volumeRoot, "root", "", "operate on a macOS volume mounted at this path")
+	rootCmd.PersistentFlags().StringVar(&targetUser, "user", "", "clean up this user's home folder (required with --root)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "output format: text or json")
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [session] [item...]",
+		Short: "Restore items removed by a previous uninstall",
+		Long: "Without arguments, lists past uninstall sessions. With a session ID, puts every item\n" +
+			"from that session back in place, or only the given items (by number or original path).",
+		Run: runRestore,
+	}
+	restoreCmd.Flags().BoolVarP(&restoreShow, "list", "l", false, "list the items in a session without restoring")
+	rootCmd.AddCommand(restoreCmd)
+
+	orphansCmd := &cobra.Command{
+		Use:   "orphans",
+		Short: "Find leftovers of applications that are no longer installed",
+		Long: "Indexes the folders zaap scans, groups entries by bundle ID vendor or name, leaves out\n" +
+			"everything that belongs to an installed application and offers the rest for removal.",
+		Run: runOrphans,
+	}
+	orphansCmd.Flags().BoolVarP(&orphansList, "list", "l", false, "list orphaned items without offering to remove them")
+	orphansCmd.Flags().BoolVar(&permanent, "permanent", false, "delete permanently instead of moving to the Trash")
+	rootCmd.AddCommand(orphansCmd)
+
+	rmCmd := &cobra.Command{
+		Use:   "rm [name|path]...",
+		Short: "Delete applications and their associated items",
+		Long: "Deletes installed applications by name, any .app bundle by path, or, with --bundle-id,\n" +
+			"the leftovers of an application that is no longer installed.",
+		Run: runRm,
+	}
+	rmCmd.Flags().StringArrayVar(&rmBundleIDs, "bundle-id", nil, "clean up items belonging to this bundle ID (repeatable)")
+	rmCmd.Flags().StringVar(&fromFile, "from-file", "", "also delete the apps listed in this file, one per line (- for stdin)")
+	rmCmd.Flags().BoolVar(&permanent, "permanent", false, "delete permanently instead of moving to the Trash")
+	rmCmd.Flags().BoolVar(&forceProtected, "force-protected", false, "allow deleting Apple, system and other protected apps and items")
+	rmCmd.Flags().BoolVar(&includeShared, "include-shared", false, "also delete items that other installed apps appear to use")
+	rmCmd.Flags().StringVar(&ifRunning, "running", "", "what to do with running apps: abort, quit or kill (default abort)")
+	rmCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "delete without asking for confirmation (required with --output json)")
+	rootCmd.AddCommand(rmCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -55,77 +215,101 @@ func run(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	if deleteName != "" {
-		deleteApp(deleteName)
+	if scanName != "" {
+		scanApp(scanName)
+		return
+	}
+
+	if len(deleteNames) > 0 || fromFile != "" {
+		deleteApps(resolveTargets(deleteNames, nil))
 		return
 	}
 
+	if jsonOutput() {
+		fmt.Fprintln(os.Stderr, "Error: --output json requires --list, --scan or --delete")
+		os.Exit(1)
+	}
 	interactiveMode()
 }
 
 func listApplications() {
-	apps, err := getApplications("/Applications")
+	apps, err := getInstalledApps()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	interruptible(func(ctx context.Context) error { return sizeApps(ctx, apps) })
+
+	switch sortOrder {
+	case "name":
+		sort.SliceStable(apps, func(i, j int) bool { return strings.ToLower(apps[i].Name) < strings.ToLower(apps[j].Name) })
+	case "size":
+		sort.SliceStable(apps, func(i, j int) bool { return apps[i].Size > apps[j].Size })
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown sort order %q (want name or size)\n", sortOrder)
+		os.Exit(1)
+	}
+
+	if jsonOutput() {
+		writeJSON(newInventoryOutput(apps))
+		return
+	}
 
 	fmt.Println("Installed Applications:")
 	fmt.Println("----------------------")
 	for i, app := range apps {
-		fmt.Printf("%d. %s\n", i+1, app.Name)
+		line := fmt.Sprintf("%d. %s  [%s]  %s", i+1, appLabel(app), displayDir(app.Path), formatSize(app.Size))
+		if app.TeamID != "" {
+			line += "  team " + app.TeamID
+		}
+		fmt.Println(line)
 	}
 }
 
 func interactiveMode() {
-	apps, err := getApplications("/Applications")
+	apps, err := getInstalledApps()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("Select an application to delete:")
-	fmt.Println("---------------------------------")
+	fmt.Println("Select applications to delete:")
+	fmt.Println("------------------------------")
 	for i, app := range apps {
-		fmt.Printf("%d. %s\n", i+1, app.Name)
+		fmt.Printf("%d. %s\n", i+1, menuLabel(app, apps))
 	}
 	fmt.Println("0. Exit")
 
-	fmt.Print("\nEnter number: ")
+	fmt.Print("\nEnter numbers (e.g. 1,4,7-9): ")
 	reader := bufio.NewReader(os.Stdin)
 	line, _ := reader.ReadString('\n')
 	line = strings.TrimSpace(line)
 
-	var selection int
-	if _, err := fmt.Sscanf(line, "%d", &selection); err != nil {
-		fmt.Println("Invalid input. Exiting.")
-		os.Exit(1)
-	}
-
-	if selection == 0 || selection < 0 || selection > len(apps) {
+	if line == "" || line == "0" {
 		fmt.Println("Exiting.")
 		os.Exit(0)
 	}
+	selection, err := parseSelection(line, len(apps))
+	if err != nil {
+		fmt.Printf("Invalid input: %v. Exiting.\n", err)
+		os.Exit(1)
+	}
 
-	app := apps[selection-1]
-	scanAssociatedFiles(&app)
-
-	fmt.Printf("\nSelected: %s\n", app.Name)
-	fmt.Printf("Location: %s\n", app.Path)
+	var targets []AppInfo
+	for _, i := range selection {
+		targets = append(targets, apps[i])
+	}
+	checkProtected(targets)
+	scanTargets(targets, apps)
 
-	printCategory("Associated files", app.AssociatedFiles)
-	printCategory("Control Panels", app.ControlPanels)
-	printCategory("Startup Items", app.StartupItems)
-	printCategory("QuickLook Plugins", app.QuickLook)
-	printCategory("Screen Savers", app.ScreenSavers)
-	printCategory("Input Methods", app.InputMethods)
-	printCategory("Fonts", app.Fonts)
+	fmt.Println()
+	total := reviewTargets(targets)
 
-	if len(app.AssociatedFiles)+len(app.ControlPanels)+len(app.StartupItems)+len(app.QuickLook)+len(app.ScreenSavers)+len(app.InputMethods)+len(app.Fonts) == 0 {
-		fmt.Println("\nNo associated items found.")
+	if len(targets) == 1 {
+		fmt.Print("\nDelete this application? (y/n): ")
+	} else {
+		fmt.Printf("\nDelete these %d applications? (y/n): ", len(targets))
 	}
-
-	fmt.Print("\nDelete this application? (y/n): ")
 	line, _ = reader.ReadString('\n')
 	line = strings.TrimSpace(line)
 
@@ -134,326 +318,181 @@ func interactiveMode() {
 		os.Exit(0)
 	}
 
-	if dryRun {
-		fmt.Printf("Would delete: %s\n", app.Path)
-	} else {
-		if err := deletePath(app.Path); err != nil {
-			fmt.Fprintf(os.Stderr, "Error deleting app: %v\n", err)
-		} else {
-			fmt.Printf("Deleted: %s\n", app.Path)
-		}
-	}
-
-	allItems := app.AssociatedFiles
-	allItems = append(allItems, app.ControlPanels...)
-	allItems = append(allItems, app.StartupItems...)
-	allItems = append(allItems, app.QuickLook...)
-	allItems = append(allItems, app.ScreenSavers...)
-	allItems = append(allItems, app.InputMethods...)
-	allItems = append(allItems, app.Fonts...)
-
-	if len(allItems) > 0 {
+	// Every question is asked before anything is stopped or deleted.
+	chosen := make([][]Finding, len(targets))
+	if total > 0 {
 		fmt.Println("\nDelete associated items? (y/n/all): ")
 		line, _ = reader.ReadString('\n')
 		line = strings.TrimSpace(line)
 
-		if line == "all" {
-			for _, f := range allItems {
-				if dryRun {
-					fmt.Printf("Would delete: %s\n", f)
-				} else {
-					if err := deletePath(f); err != nil {
-						fmt.Fprintf(os.Stderr, "Error deleting %s: %v\n", f, err)
-					} else {
-						fmt.Printf("Deleted: %s\n", f)
-					}
-				}
-			}
-		} else if strings.ToLower(line) == "y" {
-			for _, f := range allItems {
-				fmt.Printf("Delete %s? (y/n): ", filepath.Base(f))
+		var confirm func(Finding) bool
+		if strings.ToLower(line) == "y" {
+			confirm = func(f Finding) bool {
+				fmt.Printf("Delete %s? (y/n): ", filepath.Base(f.Path))
 				line, _ := reader.ReadString('\n')
-				line = strings.TrimSpace(line)
-				if strings.ToLower(line) == "y" {
-					if dryRun {
-						fmt.Printf("Would delete: %s\n", f)
-					} else {
-						if err := deletePath(f); err != nil {
-							fmt.Fprintf(os.Stderr, "Error deleting %s: %v\n", f, err)
-						} else {
-							fmt.Printf("Deleted: %s\n", f)
-						}
-					}
-				}
+				return strings.ToLower(strings.TrimSpace(line)) == "y"
 			}
 		}
+		if line == "all" || confirm != nil {
+			chosen = chooseFindings(targets, confirm)
+		}
 	}
-
-	if dryRun {
-		fmt.Println("\nDry run complete. No files were actually deleted.")
-	}
-
-	fmt.Println("\nDone!")
-}
-
-func deleteApp(name string) {
-	apps, err := getApplications("/Applications")
-	if err != nil {
+	if err := prepareTargets(targets, chosen, reader); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	var target AppInfo
-	found := false
-	for _, app := range apps {
-		if strings.EqualFold(app.Name, name) {
-			target = app
-			found = true
-			break
-		}
+	journal := startJournal()
+	out := newDeleteOutput(targets, journal)
+	for i, app := range targets {
+		out.Apps[i].Results = append(out.Apps[i].Results, removeAndReport(journal, app.Name, categoryApplication, app.Path))
 	}
-
-	if !found {
-		fmt.Printf("Application not found: %s\n", name)
-		os.Exit(1)
+	for i, app := range targets {
+		out.Apps[i].Results = append(out.Apps[i].Results, removeFindings(journal, app.Name, chosen[i])...)
 	}
 
-	scanAssociatedFiles(&target)
+	finishBatch(targets, out, journal)
 
-	fmt.Printf("Selected: %s\n", target.Name)
-	fmt.Printf("Location: %s\n", target.Path)
+	fmt.Println("\nDone!")
+}
 
-	printCategory("Associated files", target.AssociatedFiles)
-	printCategory("Control Panels", target.ControlPanels)
-	printCategory("Startup Items", target.StartupItems)
-	printCategory("QuickLook Plugins", target.QuickLook)
-	printCategory("Screen Savers", target.ScreenSavers)
-	printCategory("Input Methods", target.InputMethods)
-	printCategory("Fonts", target.Fonts)
+func scanApp(name string) {
+	targets, installed := resolveTargets([]string{name}, nil)
+	scanTargets(targets, installed)
+	target := targets[0]
 
-	action := "Would delete"
-	if !dryRun {
-		if err := deletePath(target.Path); err != nil {
-			fmt.Fprintf(os.Stderr, "Error deleting app: %v\n", err)
-			os.Exit(1)
-		}
-		action = "Deleted"
+	if jsonOutput() {
+		writeJSON(newScanOutput(target))
+		return
 	}
-	fmt.Printf("%s: %s\n", action, target.Path)
 
-	allItems := target.AssociatedFiles
-	allItems = append(allItems, target.ControlPanels...)
-	allItems = append(allItems, target.StartupItems...)
-	allItems = append(allItems, target.QuickLook...)
-	allItems = append(allItems, target.ScreenSavers...)
-	allItems = append(allItems, target.InputMethods...)
-	allItems = append(allItems, target.Fonts...)
-
-	for _, f := range allItems {
-		if dryRun {
-			fmt.Printf("Would delete: %s\n", f)
-		} else {
-			if err := deletePath(f); err != nil {
-				fmt.Fprintf(os.Stderr, "Error deleting %s: %v\n", f, err)
-			} else {
-				fmt.Printf("Deleted: %s\n", f)
-			}
-		}
+	printAppDetails(target)
+	for _, c := range target.categories() {
+		printCategory(c)
 	}
+}
 
-	if dryRun {
-		fmt.Println("\nDry run complete. No files were actually deleted.")
+// maxAppSearchDepth bounds how far below an application root we look for
+// bundles, which is enough for vendor folders and Chrome Apps.localized.
+const maxAppSearchDepth = 4
+
+func defaultAppRoots() []string {
+	return []string{
+		systemPath("/Applications"),
+		homePath("Applications"),
 	}
 }
 
-func getApplications(dir string) ([]AppInfo, error) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
+// getInstalledApps discovers applications in every configured root,
+// skipping roots that do not exist.
+func getInstalledApps() ([]AppInfo, error) {
+	roots := appDirs
+	if len(roots) == 0 {
+		roots = defaultAppRoots()
 	}
 
 	var apps []AppInfo
-	for _, entry := range entries {
-		if strings.HasSuffix(entry.Name(), ".app") {
-			apps = append(apps, AppInfo{
-				Name: strings.TrimSuffix(entry.Name(), ".app"),
-				Path: filepath.Join(dir, entry.Name()),
-			})
+	for _, root := range roots {
+		found, err := getApplications(root)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
 		}
+		apps = append(apps, found...)
 	}
 	return apps, nil
 }
 
-func scanAssociatedFiles(app *AppInfo) {
-	bundleID := getBundleID(app.Path)
-	if bundleID == "" {
-		bundleID = strings.ReplaceAll(app.Name, " ", "")
-	}
-
-	if verbose {
-		fmt.Printf("Bundle ID: %s\n", bundleID)
-	}
-
-	home := os.Getenv("HOME")
-	locations := []string{
-		filepath.Join(home, "Library/Preferences", bundleID+".plist"),
-		filepath.Join(home, "Library/Preferences", bundleID),
-		filepath.Join(home, "Library/Application Support", bundleID),
-		filepath.Join(home, "Library/Caches", bundleID),
-		filepath.Join(home, "Library/Logs", bundleID),
-		filepath.Join(home, "Library/Saved Application State", bundleID+".savedState"),
-		filepath.Join(home, "Library/Containers", bundleID),
+// getApplications walks dir for .app bundles without descending into them.
+func getApplications(dir string) ([]AppInfo, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
 	}
 
-	appSupportDir := filepath.Join(home, "Library/Application Support")
-	if entries, err := os.ReadDir(appSupportDir); err == nil {
-		for _, entry := range entries {
-			if strings.Contains(strings.ToLower(entry.Name()), strings.ToLower(app.Name)) {
-				locations = append(locations, filepath.Join(appSupportDir, entry.Name()))
+	var apps []AppInfo
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
 			}
+			return nil
 		}
-	}
-
-	prefsDir := filepath.Join(home, "Library/Preferences")
-	if entries, err := os.ReadDir(prefsDir); err == nil {
-		for _, entry := range entries {
-			if strings.HasPrefix(entry.Name(), bundleID) {
-				locations = append(locations, filepath.Join(prefsDir, entry.Name()))
-			}
+		if path == dir {
+			return nil
 		}
-	}
-
-	cachesDir := filepath.Join(home, "Library/Caches")
-	if entries, err := os.ReadDir(cachesDir); err == nil {
-		for _, entry := range entries {
-			if strings.Contains(strings.ToLower(entry.Name()), strings.ToLower(app.Name)) {
-				locations = append(locations, filepath.Join(cachesDir, entry.Name()))
+		if strings.HasSuffix(d.Name(), ".app") {
+			app := AppInfo{
+				Name: strings.TrimSuffix(d.Name(), ".app"),
+				Path: path,
+				Root: dir,
 			}
-		}
-	}
-
-	for _, loc := range locations {
-		if exists, _ := pathExists(loc); exists {
-			app.AssociatedFiles = append(app.AssociatedFiles, loc)
-		}
-	}
-
-	scanControlPanels(app, bundleID)
-	scanStartupItems(app, bundleID)
-	scanQuickLook(app, bundleID)
-	scanScreenSavers(app, bundleID)
-	scanInputMethods(app, bundleID)
-	scanFonts(app, bundleID)
-}
-
-func scanControlPanels(app *AppInfo, bundleID string) {
-	home := os.Getenv("HOME")
-	locations := []string{
-		filepath.Join(home, "Library/PreferencePanes"),
-		"/Library/PreferencePanes",
-	}
-	for _, dir := range locations {
-		if entries, err := os.ReadDir(dir); err == nil {
-			for _, entry := range entries {
-				if strings.Contains(strings.ToLower(entry.Name()), strings.ToLower(app.Name)) {
-					app.ControlPanels = append(app.ControlPanels, filepath.Join(dir, entry.Name()))
-				}
+			if err := loadBundleInfo(&app); err != nil && verbose {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", app.Path, err)
+			}
+			apps = append(apps, app)
+			if d.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
 		}
-	}
-}
-
-func scanStartupItems(app *AppInfo, bundleID string) {
-	home := os.Getenv("HOME")
-	locations := []string{
-		filepath.Join(home, "Library/LaunchAgents"),
-		"/Library/LaunchAgents",
-		filepath.Join(home, "Library/LaunchDaemons"),
-		"/Library/LaunchDaemons",
-	}
-	for _, dir := range locations {
-		if entries, err := os.ReadDir(dir); err == nil {
-			for _, entry := range entries {
-				name := strings.ToLower(entry.Name())
-				appName := strings.ToLower(app.Name)
-				if strings.Contains(name, bundleID) || strings.Contains(name, appName) {
-					app.StartupItems = append(app.StartupItems, filepath.Join(dir, entry.Name()))
-				}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || strings.Count(strings.TrimPrefix(path, dir), string(filepath.Separator)) >= maxAppSearchDepth {
+				return filepath.SkipDir
 			}
 		}
-	}
+		return nil
+	})
+	return apps, err
 }
 
-func scanQuickLook(app *AppInfo, bundleID string) {
-	home := os.Getenv("HOME")
-	locations := []string{
-		filepath.Join(home, "Library/QuickLook"),
-		"/Library/QuickLook",
-	}
-	for _, dir := range locations {
-		if entries, err := os.ReadDir(dir); err == nil {
-			for _, entry := range entries {
-				if strings.Contains(strings.ToLower(entry.Name()), strings.ToLower(app.Name)) {
-					app.QuickLook = append(app.QuickLook, filepath.Join(dir, entry.Name()))
-				}
-			}
-		}
+// matchBundleIDFor returns the bundle ID to match app's items against,
+// falling back to the name without spaces for apps that have none.
+func matchBundleIDFor(app *AppInfo) string {
+	bundleID := app.BundleID
+	if bundleID == "" {
+		bundleID = getBundleID(app.Path)
 	}
+	if bundleID == "" {
+		bundleID = strings.ReplaceAll(app.Name, " ", "")
+	}
+	return bundleID
 }
 
-func scanScreenSavers(app *AppInfo, bundleID string) {
-	home := os.Getenv("HOME")
-	dir := filepath.Join(home, "Library/Screen Savers")
-	if entries, err := os.ReadDir(dir); err == nil {
-		for _, entry := range entries {
-			if strings.Contains(strings.ToLower(entry.Name()), strings.ToLower(app.Name)) {
-				app.ScreenSavers = append(app.ScreenSavers, filepath.Join(dir, entry.Name()))
-			}
+// scanAssociatedFiles sizes apps and fills in their findings, listing the
+// Library once for all of them.
+func scanAssociatedFiles(ctx context.Context, apps ...*AppInfo) error {
+	if verbose && !jsonOutput() {
+		for _, app := range apps {
+			fmt.Printf("Bundle ID: %s\n", matchBundleIDFor(app))
 		}
 	}
-}
 
-func scanInputMethods(app *AppInfo, bundleID string) {
-	home := os.Getenv("HOME")
-	locations := []string{
-		filepath.Join(home, "Library/Input Methods"),
-		"/Library/Input Methods",
-	}
-	for _, dir := range locations {
-		if entries, err := os.ReadDir(dir); err == nil {
-			for _, entry := range entries {
-				if strings.Contains(strings.ToLower(entry.Name()), strings.ToLower(app.Name)) {
-					app.InputMethods = append(app.InputMethods, filepath.Join(dir, entry.Name()))
-				}
-			}
-		}
+	idx, err := buildLibraryIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if err := scanApps(ctx, idx, apps); err != nil {
+		return err
 	}
-}
 
-func scanFonts(app *AppInfo, bundleID string) {
-	home := os.Getenv("HOME")
-	locations := []string{
-		filepath.Join(home, "Library/Fonts"),
-		"/Library/Fonts",
-	}
-	for _, dir := range locations {
-		if entries, err := os.ReadDir(dir); err == nil {
-			for _, entry := range entries {
-				if strings.Contains(strings.ToLower(entry.Name()), strings.ToLower(app.Name)) {
-					app.Fonts = append(app.Fonts, filepath.Join(dir, entry.Name()))
-				}
+	if verbose && !jsonOutput() {
+		for _, app := range apps {
+			for _, h := range app.Helpers {
+				fmt.Printf("Helper: %s (%s)\n", h.BundleID, h.Path)
 			}
 		}
 	}
+	return nil
 }
 
 func getBundleID(appPath string) string {
-	cmd := exec.Command("defaults", "read", filepath.Join(appPath, "Contents/Info"), "CFBundleIdentifier")
-	output, err := cmd.Output()
+	info, err := readPlistDict(filepath.Join(appPath, "Contents", "Info.plist"))
 	if err != nil {
 		return ""
 	}
-	return strings.TrimSpace(string(output))
+	return strings.TrimSpace(plistString(info, "CFBundleIdentifier"))
 }
 
 func pathExists(path string) (bool, error) {
@@ -468,14 +507,127 @@ func pathExists(path string) (bool, error) {
 }
 
 func deletePath(path string) error {
-	return os.RemoveAll(path)
+	_, err := removePath(path)
+	return err
 }
 
-func printCategory(name string, items []string) {
-	if len(items) > 0 {
-		fmt.Printf("\n%s:\n", name)
-		for _, f := range items {
-			fmt.Printf("  - %s\n", f)
+// removePath trashes or permanently deletes path, returning where a trashed
+// item was moved to. Paths refused by checkRemovable are left alone.
+func removePath(path string) (string, error) {
+	if err := checkRemovable(path); err != nil {
+		return "", err
+	}
+	if permanent {
+		return "", os.RemoveAll(path)
+	}
+	return moveToTrash(path)
+}
+
+func removeAndReport(journal *journalSession, appName, category, path string) deleteResult {
+	result := deleteResult{Path: path, Category: category, Action: actionName()}
+	var err error
+	if dryRun {
+		err = checkRemovable(path)
+	} else {
+		if category == "startup-items" {
+			// Unload the job first so it does not keep running, or
+			// respawning, once its plist or program is gone.
+			if err = checkRemovable(path); err == nil {
+				result.Unloaded, err = unloadStartupItem(path)
+			}
+		}
+		if err == nil {
+			err = journal.remove(appName, path)
+		}
+	}
+	if err != nil {
+		result.Action = "failed"
+		result.Error = err.Error()
+		fmt.Fprintf(os.Stderr, "Error deleting %s: %v\n", path, err)
+		return result
+	}
+	if !jsonOutput() {
+		fmt.Printf("%s: %s\n", actionVerb(), path)
+	}
+	return result
+}
+
+func startJournal() *journalSession {
+	if dryRun {
+		return nil
+	}
+	journal, err := newJournalSession()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not create undo journal: %v\n", err)
+		return nil
+	}
+	return journal
+}
+
+func printUndoHint(journal *journalSession) {
+	if jsonOutput() || journal == nil || len(journal.Items) == 0 || permanent {
+		return
+	}
+	fmt.Printf("\nTo undo, run: zaap restore %s\n", journal.ID)
+}
+
+// actionName is the machine-readable form of actionVerb.
+func actionName() string {
+	switch {
+	case dryRun && permanent:
+		return "would-delete"
+	case dryRun:
+		return "would-trash"
+	case permanent:
+		return "deleted"
+	}
+	return "trashed"
+}
+
+func actionVerb() string {
+	switch {
+	case dryRun && permanent:
+		return "Would delete"
+	case dryRun:
+		return "Would move to Trash"
+	case permanent:
+		return "Deleted"
+	}
+	return "Moved to Trash"
+}
+
+func printCategory(c category) {
+	if len(c.Items) > 0 {
+		if c.Risk == riskHigh {
+			fmt.Printf("\n%s (high risk, %s):\n", c.Title, formatSize(c.size()))
+		} else {
+			fmt.Printf("\n%s (%s):\n", c.Title, formatSize(c.size()))
+		}
+		for _, f := range c.Items {
+			how := f.Rule.description()
+			if f.Key != "" {
+				how += " in " + f.Key
+			}
+			if f.Helper != "" {
+				how += " of helper " + f.Helper
+			}
+			line := fmt.Sprintf("  - %s (%s, %s)", f.Path, formatSize(f.Size), how)
+			if note := f.note(); note != "" {
+				line += " " + note
+			}
+			fmt.Println(line)
 		}
 	}
 }
+
+func printReclaimable(apps []AppInfo) {
+	verb := "will"
+	if dryRun {
+		verb = "would"
+	}
+	line := fmt.Sprintf("\nThis %s free %s", verb, formatSize(totalReclaimable(apps)))
+	if !permanent {
+		line += " once the Trash is emptied"
+	}
+	fmt.Println(line + ".")
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"os"
 	"path/filepath"
-	"runtime"
 	"testing"
 )
 
@@ -48,6 +48,16 @@ func newTestFS(t *testing.T) *testFS {
 	}
 }
 
+func findingPaths(app AppInfo, category string) []string {
+	var paths []string
+	for _, f := range app.Findings {
+		if f.Category == category {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
 func (fs *testFS) createApp(t *testing.T, name, bundleID string) string {
 	appsDir := filepath.Join(fs.homeDir, "Applications")
 	appPath := filepath.Join(appsDir, name+".app")
@@ -183,20 +193,99 @@ func TestGetApplications(t *testing.T) {
 	}
 }
 
+func TestGetApplicationsRecursive(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	appsDir := filepath.Join(fs.homeDir, "Applications")
+	fs.createApp(t, "TestApp", "com.test.app")
+
+	nested := map[string]string{
+		"Utilities/Console.app":                         "com.test.console",
+		"Adobe Foo/Foo.app":                             "com.adobe.foo",
+		"Chrome Apps.localized/Docs.app":                "com.google.chrome.app.docs",
+		"TestApp.app/Contents/Library/LoginItems/H.app": "com.test.app.helper",
+		".hidden/Secret.app":                            "com.test.secret",
+		"a/b/c/d/e/Deep.app":                            "com.test.deep",
+	}
+	for rel, bundleID := range nested {
+		path := filepath.Join(appsDir, rel)
+		if err := os.MkdirAll(filepath.Join(path, "Contents"), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", rel, err)
+		}
+		plist := `<plist><dict><key>CFBundleIdentifier</key><string>` + bundleID + `</string></dict></plist>`
+		if err := os.WriteFile(filepath.Join(path, "Contents", "Info.plist"), []byte(plist), 0644); err != nil {
+			t.Fatalf("failed to write Info.plist: %v", err)
+		}
+	}
+
+	apps, err := getApplications(appsDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := map[string]AppInfo{}
+	for _, app := range apps {
+		found[app.Name] = app
+		if app.Root != appsDir {
+			t.Errorf("%s: expected root %s, got %s", app.Name, appsDir, app.Root)
+		}
+	}
+	for _, name := range []string{"TestApp", "Console", "Foo", "Docs"} {
+		if _, ok := found[name]; !ok {
+			t.Errorf("expected to find %s", name)
+		}
+	}
+	for _, name := range []string{"H", "Secret", "Deep"} {
+		if _, ok := found[name]; ok {
+			t.Errorf("did not expect to find %s", name)
+		}
+	}
+	if found["Foo"].BundleID != "com.adobe.foo" {
+		t.Errorf("expected nested app bundle info to be loaded, got %+v", found["Foo"])
+	}
+}
+
+func TestGetInstalledAppsRoots(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	otherRoot := filepath.Join(fs.rootDir, "Applications")
+	if err := os.MkdirAll(filepath.Join(otherRoot, "TestApp.app"), 0755); err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	fs.createApp(t, "TestApp", "com.test.app")
+
+	appDirs = []string{otherRoot, filepath.Join(fs.homeDir, "Applications"), filepath.Join(fs.rootDir, "missing")}
+	defer func() { appDirs = nil }()
+
+	apps, err := getInstalledApps()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apps) != 2 {
+		t.Fatalf("expected 2 apps, got %d", len(apps))
+	}
+
+	if got := menuLabel(apps[1], apps); got != "TestApp  [~/Applications]" {
+		t.Errorf("expected duplicate to be disambiguated, got %q", got)
+	}
+	if got := menuLabel(apps[0], apps); got != "TestApp  ["+otherRoot+"]" {
+		t.Errorf("expected duplicate to be disambiguated, got %q", got)
+	}
+	if got := menuLabel(apps[0], apps[:1]); got != "TestApp" {
+		t.Errorf("unique app should not show its folder, got %q", got)
+	}
+}
+
 func TestGetBundleID(t *testing.T) {
 	fs := newTestFS(t)
 
 	appPath := fs.createApp(t, "Chrome", "com.google.Chrome")
 
 	bundleID := getBundleID(appPath)
-	if runtime.GOOS == "darwin" {
-		if bundleID != "com.google.Chrome" {
-			t.Errorf("expected bundle ID com.google.Chrome, got %s", bundleID)
-		}
-	} else {
-		if bundleID != "" {
-			t.Errorf("expected empty bundle ID on non-macOS, got %s", bundleID)
-		}
+	if bundleID != "com.google.Chrome" {
+		t.Errorf("expected bundle ID com.google.Chrome, got %s", bundleID)
 	}
 
 	nonexistent := getBundleID("/nonexistent/app.app")
@@ -227,9 +316,9 @@ func TestScanAssociatedFilesWithBundleID(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanAssociatedFiles(&app)
+	scanAssociatedFiles(context.Background(), &app)
 
-	if len(app.AssociatedFiles) == 0 {
+	if len(findingPaths(app, "associated-files")) == 0 {
 		t.Error("expected associated files to be found")
 	}
 
@@ -237,7 +326,7 @@ func TestScanAssociatedFilesWithBundleID(t *testing.T) {
 	foundAppSupport := false
 	foundCaches := false
 
-	for _, f := range app.AssociatedFiles {
+	for _, f := range findingPaths(app, "associated-files") {
 		if filepath.Base(f) == bundleID+".plist" || filepath.Base(f) == "TestApp.plist" {
 			foundPrefs = true
 		}
@@ -274,14 +363,14 @@ func TestScanControlPanels(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanControlPanels(&app, bundleID)
+	found := scannerByID("control-panels").Scan(newLibraryIndex(), &app, newMatcher(&app, bundleID))
 
-	if len(app.ControlPanels) != 1 {
-		t.Fatalf("expected 1 control panel, got %d", len(app.ControlPanels))
+	if len(found) != 1 {
+		t.Fatalf("expected 1 control panel, got %d", len(found))
 	}
 
-	if app.ControlPanels[0] != panePath {
-		t.Errorf("expected %s, got %s", panePath, app.ControlPanels[0])
+	if found[0].Path != panePath {
+		t.Errorf("expected %s, got %s", panePath, found[0].Path)
 	}
 }
 
@@ -299,14 +388,14 @@ func TestScanScreenSavers(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanScreenSavers(&app, bundleID)
+	found := scannerByID("screen-savers").Scan(newLibraryIndex(), &app, newMatcher(&app, bundleID))
 
-	if len(app.ScreenSavers) != 1 {
-		t.Fatalf("expected 1 screen saver, got %d", len(app.ScreenSavers))
+	if len(found) != 1 {
+		t.Fatalf("expected 1 screen saver, got %d", len(found))
 	}
 
-	if app.ScreenSavers[0] != saverPath {
-		t.Errorf("expected %s, got %s", saverPath, app.ScreenSavers[0])
+	if found[0].Path != saverPath {
+		t.Errorf("expected %s, got %s", saverPath, found[0].Path)
 	}
 }
 
@@ -324,14 +413,14 @@ func TestScanInputMethods(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanInputMethods(&app, bundleID)
+	found := scannerByID("input-methods").Scan(newLibraryIndex(), &app, newMatcher(&app, bundleID))
 
-	if len(app.InputMethods) != 1 {
-		t.Fatalf("expected 1 input method, got %d", len(app.InputMethods))
+	if len(found) != 1 {
+		t.Fatalf("expected 1 input method, got %d", len(found))
 	}
 
-	if app.InputMethods[0] != inputPath {
-		t.Errorf("expected %s, got %s", inputPath, app.InputMethods[0])
+	if found[0].Path != inputPath {
+		t.Errorf("expected %s, got %s", inputPath, found[0].Path)
 	}
 }
 
@@ -349,14 +438,14 @@ func TestScanFonts(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanFonts(&app, bundleID)
+	found := scannerByID("fonts").Scan(newLibraryIndex(), &app, newMatcher(&app, bundleID))
 
-	if len(app.Fonts) != 1 {
-		t.Fatalf("expected 1 font, got %d", len(app.Fonts))
+	if len(found) != 1 {
+		t.Fatalf("expected 1 font, got %d", len(found))
 	}
 
-	if app.Fonts[0] != fontPath {
-		t.Errorf("expected %s, got %s", fontPath, app.Fonts[0])
+	if found[0].Path != fontPath {
+		t.Errorf("expected %s, got %s", fontPath, found[0].Path)
 	}
 }
 
@@ -374,14 +463,14 @@ func TestScanQuickLook(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanQuickLook(&app, bundleID)
+	found := scannerByID("quicklook-plugins").Scan(newLibraryIndex(), &app, newMatcher(&app, bundleID))
 
-	if len(app.QuickLook) != 1 {
-		t.Fatalf("expected 1 quicklook plugin, got %d", len(app.QuickLook))
+	if len(found) != 1 {
+		t.Fatalf("expected 1 quicklook plugin, got %d", len(found))
 	}
 
-	if app.QuickLook[0] != qlPath {
-		t.Errorf("expected %s, got %s", qlPath, app.QuickLook[0])
+	if found[0].Path != qlPath {
+		t.Errorf("expected %s, got %s", qlPath, found[0].Path)
 	}
 }
 
@@ -399,21 +488,22 @@ func TestScanStartupItems(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanStartupItems(&app, bundleID)
+	found := scannerByID("startup-items").Scan(newLibraryIndex(), &app, newMatcher(&app, bundleID))
 
-	if len(app.StartupItems) != 1 {
-		t.Fatalf("expected 1 startup item, got %d", len(app.StartupItems))
+	if len(found) != 1 {
+		t.Fatalf("expected 1 startup item, got %d", len(found))
 	}
 
-	if app.StartupItems[0] != agentPath {
-		t.Errorf("expected %s, got %s", agentPath, app.StartupItems[0])
+	if found[0].Path != agentPath {
+		t.Errorf("expected %s, got %s", agentPath, found[0].Path)
 	}
 }
 
 func TestDeletePath(t *testing.T) {
 	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
 
-	tmpFile := filepath.Join(fs.rootDir, "test.txt")
+	tmpFile := filepath.Join(fs.homeDir, "Library", "Caches", "test.txt")
 	if err := os.WriteFile(tmpFile, []byte("test"), 0644); err != nil {
 		t.Fatalf("failed to create temp file: %v", err)
 	}
@@ -426,11 +516,40 @@ func TestDeletePath(t *testing.T) {
 		t.Error("file should have been deleted")
 	}
 
+	if exists, _ := pathExists(filepath.Join(fs.homeDir, ".Trash", "test.txt")); !exists {
+		t.Error("file should have been moved to the Trash")
+	}
+
 	if err := deletePath("/nonexistent/path"); err != nil {
 		t.Logf("expected error for non-existent path: %v", err)
 	}
 }
 
+func TestDeletePathPermanent(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	permanent = true
+	defer func() { permanent = false }()
+
+	tmpDir := filepath.Join(fs.homeDir, "Library", "Caches", "dir")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "nested"), 0755); err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+
+	if err := deletePath(tmpDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exists, _ := pathExists(tmpDir); exists {
+		t.Error("directory should have been deleted")
+	}
+
+	if exists, _ := pathExists(filepath.Join(fs.homeDir, ".Trash")); exists {
+		t.Error("permanent deletion should not use the Trash")
+	}
+}
+
 func TestDeleteAppAndAssociatedFiles(t *testing.T) {
 	fs := newTestFS(t)
 	os.Setenv("HOME", fs.homeDir)
@@ -443,9 +562,13 @@ func TestDeleteAppAndAssociatedFiles(t *testing.T) {
 	cachesPath := fs.createCachesDir(t, bundleID)
 
 	app := AppInfo{
-		Name:            "TestApp",
-		Path:            appPath,
-		AssociatedFiles: []string{prefPath, appSupportPath, cachesPath},
+		Name: "TestApp",
+		Path: appPath,
+		Findings: []Finding{
+			{Path: prefPath, Category: "associated-files"},
+			{Path: appSupportPath, Category: "associated-files"},
+			{Path: cachesPath, Category: "associated-files"},
+		},
 	}
 
 	if err := deletePath(app.Path); err != nil {
@@ -456,15 +579,15 @@ func TestDeleteAppAndAssociatedFiles(t *testing.T) {
 		t.Error("app should have been deleted")
 	}
 
-	for _, f := range app.AssociatedFiles {
-		if err := deletePath(f); err != nil {
-			t.Fatalf("failed to delete associated file %s: %v", f, err)
+	for _, f := range app.Findings {
+		if err := deletePath(f.Path); err != nil {
+			t.Fatalf("failed to delete associated file %s: %v", f.Path, err)
 		}
 	}
 
-	for _, f := range app.AssociatedFiles {
-		if exists, _ := pathExists(f); exists {
-			t.Errorf("associated file should have been deleted: %s", f)
+	for _, f := range app.Findings {
+		if exists, _ := pathExists(f.Path); exists {
+			t.Errorf("associated file should have been deleted: %s", f.Path)
 		}
 	}
 }
@@ -498,40 +621,40 @@ func TestFullScanWithMultipleAssociatedFiles(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanAssociatedFiles(&app)
+	scanAssociatedFiles(context.Background(), &app)
 
-	if len(app.AssociatedFiles) == 0 {
+	if len(findingPaths(app, "associated-files")) == 0 {
 		t.Error("expected associated files to be found")
 	}
 
-	if len(app.ControlPanels) == 0 {
+	if len(findingPaths(app, "control-panels")) == 0 {
 		t.Error("expected control panels to be found")
 	}
 
-	if len(app.StartupItems) == 0 {
+	if len(findingPaths(app, "startup-items")) == 0 {
 		t.Error("expected startup items to be found")
 	}
 
-	if len(app.ScreenSavers) == 0 {
+	if len(findingPaths(app, "screen-savers")) == 0 {
 		t.Error("expected screen savers to be found")
 	}
 
-	if len(app.InputMethods) == 0 {
+	if len(findingPaths(app, "input-methods")) == 0 {
 		t.Error("expected input methods to be found")
 	}
 
-	if len(app.Fonts) == 0 {
+	if len(findingPaths(app, "fonts")) == 0 {
 		t.Error("expected fonts to be found")
 	}
 
-	if len(app.QuickLook) == 0 {
+	if len(findingPaths(app, "quicklook-plugins")) == 0 {
 		t.Error("expected quicklook plugins to be found")
 	}
 
-	t.Logf("BundleID used: com.google.chrome (or fallback GoogleChrome on non-macOS)")
+	t.Logf("BundleID used: com.google.chrome")
 	t.Logf("AssociatedFiles: %d, ControlPanels: %d, StartupItems: %d, ScreenSavers: %d, InputMethods: %d, Fonts: %d, QuickLook: %d",
-		len(app.AssociatedFiles), len(app.ControlPanels), len(app.StartupItems),
-		len(app.ScreenSavers), len(app.InputMethods), len(app.Fonts), len(app.QuickLook))
+		len(findingPaths(app, "associated-files")), len(findingPaths(app, "control-panels")), len(findingPaths(app, "startup-items")),
+		len(findingPaths(app, "screen-savers")), len(findingPaths(app, "input-methods")), len(findingPaths(app, "fonts")), len(findingPaths(app, "quicklook-plugins")))
 }
 
 func TestAppWithNoBundleID(t *testing.T) {
@@ -545,9 +668,9 @@ func TestAppWithNoBundleID(t *testing.T) {
 		Path: appPath,
 	}
 
-	scanAssociatedFiles(&app)
+	scanAssociatedFiles(context.Background(), &app)
 
-	t.Logf("Associated files: %v", app.AssociatedFiles)
+	t.Logf("Associated files: %v", findingPaths(app, "associated-files"))
 	t.Logf("BundleID used for scanning: com.testapp (fallback from app name)")
 }
 
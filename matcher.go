@@ -0,0 +1,280 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A matcher decides whether a directory entry belongs to an app. Every
+// scanner goes through it so that names, bundle IDs and vendors are
+// compared the same way everywhere.
+type matcher struct {
+	bundleIDs []string
+	names     [][]string
+	vendors   []string
+	teamID    string
+	appGroups []string
+}
+
+// reverseDNSRoots are the first components that mark a name as a
+// reverse-DNS identifier such as com.getdropbox.dropbox.
+var reverseDNSRoots = map[string]bool{
+	"ai": true, "app": true, "at": true, "au": true, "be": true, "biz": true,
+	"ca": true, "cc": true, "ch": true, "cn": true, "co": true, "com": true,
+	"cz": true, "de": true, "dev": true, "dk": true, "edu": true, "es": true,
+	"eu": true, "fi": true, "fr": true, "gov": true, "info": true, "io": true,
+	"it": true, "jp": true, "kr": true, "me": true, "net": true, "nl": true,
+	"no": true, "org": true, "pl": true, "ru": true, "se": true, "tv": true,
+	"uk": true, "us": true,
+}
+
+// genericVendors are domains shared by unrelated apps, so they say nothing
+// about who made something.
+var genericVendors = map[string]bool{
+	"app": true, "apps": true, "company": true, "electron": true, "example": true,
+	"github": true, "gitlab": true, "googlecode": true, "mac": true, "macos": true,
+	"osx": true, "sourceforge": true, "yourcompany": true,
+}
+
+// leftoverExtensions are suffixes that do not change which bundle ID an
+// entry is named after.
+var leftoverExtensions = map[string]bool{
+	".binarycookies": true, ".db": true, ".json": true, ".lock": true,
+	".log": true, ".plist": true, ".sqlite": true,
+}
+
+func newMatcher(app *AppInfo, bundleIDs ...string) *matcher {
+	m := &matcher{}
+	for _, id := range bundleIDs {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" {
+			continue
+		}
+		m.bundleIDs = append(m.bundleIDs, id)
+		if v := vendorOf(id); v != "" && !slices.Contains(m.vendors, v) {
+			m.vendors = append(m.vendors, v)
+		}
+	}
+	m.teamID = strings.ToLower(app.TeamID)
+	for _, group := range app.AppGroups {
+		m.appGroups = append(m.appGroups, strings.ToLower(group))
+	}
+	for _, name := range []string{app.Name, app.DisplayName} {
+		tokens := stripVersion(tokenize(name))
+		if len(tokens) > 0 && !slices.ContainsFunc(m.names, func(n []string) bool { return slices.Equal(n, tokens) }) {
+			m.names = append(m.names, tokens)
+		}
+	}
+	return m
+}
+
+// match reports the strongest of the allowed rules that entry satisfies.
+func (m *matcher) match(entry string, allowed []matchRule) (matchRule, bool) {
+	for _, rule := range matchRules {
+		if !slices.Contains(allowed, rule) {
+			continue
+		}
+		if m.matches(rule, entry) {
+			return rule, true
+		}
+	}
+	return 0, false
+}
+
+func (m *matcher) matches(rule matchRule, entry string) bool {
+	lower := strings.ToLower(norm.NFC.String(entry))
+	// Bundle IDs may themselves end in something that looks like an
+	// extension (com.test.app), so compare with and without it.
+	base := stripLeftoverExtension(lower)
+
+	switch rule {
+	case matchBundleID:
+		for _, id := range m.bundleIDs {
+			if lower == id || base == id {
+				return true
+			}
+		}
+	case matchAppGroup:
+		return slices.Contains(m.appGroups, lower)
+	case matchBundlePrefix:
+		for _, id := range m.bundleIDs {
+			if hasIDPrefix(lower, id) || hasIDPrefix(base, id) {
+				return true
+			}
+		}
+	case matchTeamID:
+		return m.teamID != "" && strings.HasPrefix(lower, m.teamID+".")
+	case matchName:
+		tokens := tokenize(entry)
+		if parts := strings.Split(base, "."); isReverseDNS(parts) {
+			// Only the product part of com.vendor.Product counts as a name.
+			original := strings.Split(stripLeftoverExtension(entry), ".")
+			tokens = tokenize(strings.Join(original[2:], " "))
+		}
+		ext := tokenize(filepath.Ext(entry))
+		for _, name := range m.names {
+			// "Code" must not claim "CodeRunner": whatever follows the
+			// name, other than an extension, has to say what part of the
+			// app the entry is.
+			if rest, ok := hasTokenPrefix(tokens, name); ok && (len(rest) == 0 || slices.Equal(rest, ext) || isNameSuffix(rest[0])) {
+				return true
+			}
+		}
+	case matchVendor:
+		if parts := strings.Split(base, "."); isReverseDNS(parts) {
+			return slices.Contains(m.vendors, normalizeVendor(parts[1]))
+		}
+		tokens := tokenize(entry)
+		for _, v := range m.vendors {
+			if _, ok := hasTokenPrefix(tokens, []string{v}); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasIDPrefix reports whether name continues id with a separator, so
+// com.test.app.helper extends com.test.app but com.test.apple does not.
+func hasIDPrefix(name, id string) bool {
+	return len(name) > len(id) && strings.HasPrefix(name, id) && strings.ContainsRune(".-_ ", rune(name[len(id)]))
+}
+
+func stripLeftoverExtension(name string) string {
+	if ext := filepath.Ext(name); leftoverExtensions[strings.ToLower(ext)] || isBundleExtension(ext) {
+		return strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+func isReverseDNS(parts []string) bool {
+	return len(parts) >= 3 && reverseDNSRoots[parts[0]] && parts[1] != ""
+}
+
+// vendorOf derives the vendor token from a bundle ID, e.g. "dropbox" from
+// com.getdropbox.dropbox.
+func vendorOf(bundleID string) string {
+	parts := strings.Split(strings.ToLower(bundleID), ".")
+	if !isReverseDNS(parts) {
+		return ""
+	}
+	return normalizeVendor(parts[1])
+}
+
+func normalizeVendor(domain string) string {
+	v := strings.Join(tokenize(domain), "")
+	for _, prefix := range []string{"get", "try", "use"} {
+		if strings.HasPrefix(v, prefix) && len(v)-len(prefix) >= 3 {
+			v = strings.TrimPrefix(v, prefix)
+			break
+		}
+	}
+	if genericVendors[v] || len(v) < 3 {
+		return ""
+	}
+	return v
+}
+
+// tokenize splits s into lower-case words, folding accents and breaking on
+// punctuation, spaces and camelCase boundaries.
+func tokenize(s string) []string {
+	var folded []rune
+	for _, r := range norm.NFKD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			folded = append(folded, r)
+		}
+	}
+
+	var tokens []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			tokens = append(tokens, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+	for i, r := range folded {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(current) > 0 && unicode.IsUpper(r) {
+			prev := folded[i-1]
+			nextLower := i+1 < len(folded) && unicode.IsLower(folded[i+1])
+			if unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return tokens
+}
+
+// stripVersion drops trailing version tokens such as "2", "v3" or "2024",
+// as long as something is left.
+func stripVersion(tokens []string) []string {
+	for len(tokens) > 1 && isVersionToken(tokens[len(tokens)-1]) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+func isVersionToken(tok string) bool {
+	tok = strings.TrimPrefix(tok, "v")
+	if tok == "" {
+		return false
+	}
+	for _, r := range tok {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasTokenPrefix reports whether tokens begin with name on a word boundary
+// and returns the tokens after it. Tokens are compared by concatenation so
+// "GoogleChrome", "google-chrome" and "googlechrome" all start with the
+// name "Google Chrome".
+func hasTokenPrefix(tokens, name []string) ([]string, bool) {
+	want := strings.Join(name, "")
+	if want == "" {
+		return nil, false
+	}
+	got := ""
+	for i, tok := range tokens {
+		got += tok
+		if got == want {
+			return tokens[i+1:], true
+		}
+		if !strings.HasPrefix(want, got) {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// nameSuffixes are words that, following an app's name, name a part of
+// that app rather than another product.
+var nameSuffixes = map[string]bool{
+	"agent": true, "backup": true, "backups": true, "cache": true,
+	"caches": true, "client": true, "crash": true, "crashpad": true,
+	"daemon": true, "data": true, "extension": true, "extensions": true,
+	"helper": true, "helpers": true, "importer": true, "installer": true,
+	"launcher": true, "login": true, "logs": true, "plugin": true,
+	"plugins": true, "preferences": true, "prefs": true, "preview": true,
+	"ql": true, "quicklook": true, "service": true, "services": true,
+	"settings": true, "support": true, "sync": true, "update": true,
+	"updater": true, "updates": true,
+}
+
+// isNameSuffix reports whether tok may follow an app's name in an entry
+// that still belongs to the app: a known suffix or a version.
+func isNameSuffix(tok string) bool {
+	return nameSuffixes[tok] || isVersionToken(tok)
+}
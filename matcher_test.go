@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := map[string][]string{
+		"Google Chrome":           {"google", "chrome"},
+		"GoogleChrome":            {"google", "chrome"},
+		"google-chrome":           {"google", "chrome"},
+		"HTTPServer_v2":           {"http", "server", "v2"},
+		"Café Déjà Vu":            {"cafe", "deja", "vu"},
+		"DropboxUpdateClient.log": {"dropbox", "update", "client", "log"},
+		"1Password 7":             {"1password", "7"},
+	}
+	for in, want := range tests {
+		if got := tokenize(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("tokenize(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestVendorOf(t *testing.T) {
+	tests := map[string]string{
+		"com.getdropbox.dropbox": "dropbox",
+		"us.zoom.xos":            "zoom",
+		"com.google.Chrome":      "google",
+		"com.github.Electron":    "",
+		"TestApp":                "",
+		"com.io.x":               "",
+	}
+	for id, want := range tests {
+		if got := vendorOf(id); got != want {
+			t.Errorf("vendorOf(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	chrome := newMatcher(&AppInfo{Name: "Google Chrome"}, "com.google.Chrome")
+	notes := newMatcher(&AppInfo{Name: "Notes"}, "com.apple.Notes")
+	dropbox := newMatcher(&AppInfo{Name: "Dropbox"}, "com.getdropbox.dropbox")
+	office := newMatcher(&AppInfo{Name: "Microsoft Word 2019"}, "com.microsoft.Word")
+	accented := newMatcher(&AppInfo{Name: "Café"}, "com.test.cafe")
+	code := newMatcher(&AppInfo{Name: "Code"}, "com.microsoft.VSCode")
+	mail := newMatcher(&AppInfo{Name: "Mail"}, "com.apple.mail")
+
+	tests := []struct {
+		name  string
+		m     *matcher
+		entry string
+		rule  matchRule
+		ok    bool
+	}{
+		{"exact bundle ID ignores case", chrome, "com.google.chrome", matchBundleID, true},
+		{"exact bundle ID with extension", chrome, "com.google.Chrome.plist", matchBundleID, true},
+		{"saved state", chrome, "com.google.Chrome.savedState", matchBundleID, true},
+		{"bundle ID prefix", chrome, "com.google.Chrome.helper.plist", matchBundlePrefix, true},
+		{"prefix needs a boundary", chrome, "com.google.Chromebook", matchVendor, true},
+		{"spaced name", chrome, "Google Chrome", matchName, true},
+		{"camel case name", chrome, "GoogleChrome", matchName, true},
+		{"hyphenated name", chrome, "google-chrome", matchName, true},
+		{"squashed name", chrome, "googlechrome", matchName, true},
+		{"name followed by more words", chrome, "Google Chrome Helper", matchName, true},
+		{"name inside another word", dropbox, "Dropboxer", 0, false},
+		{"name not at the start", notes, "Sticky Notes", 0, false},
+		{"name as a later word", notes, "com.sticky.StickyNotes", 0, false},
+		{"name in product part", dropbox, "DropboxUpdateClient", matchName, true},
+		{"vendor from get-prefixed domain", dropbox, "com.dropbox.DropboxUpdater.wake.plist", matchName, true},
+		{"vendor only", dropbox, "com.dropbox.dropboxmacupdate.agent.plist", matchVendor, true},
+		{"vendor directory", dropbox, "com.getdropbox.sync-helper", matchVendor, true},
+		{"version suffix", office, "Microsoft Word 2016", matchName, true},
+		{"shared vendor folder", office, "Microsoft", matchVendor, true},
+		{"accents", accented, "Cafe Support", matchName, true},
+		{"unrelated", chrome, "Firefox", 0, false},
+		{"name followed by another product", code, "CodeRunner", 0, false},
+		{"name followed by another product in a bundle ID", mail, "com.freron.MailMate", 0, false},
+		{"name followed by another word", mail, "MailMate", 0, false},
+		{"name followed by a known suffix", code, "Code Helper", matchName, true},
+		{"name followed by a version", mail, "Mail 2", matchName, true},
+		{"name followed by a plugin suffix", dropbox, "DropboxQL.qlgenerator", matchName, true},
+		{"name followed by another word falls back to the vendor", dropbox, "com.getdropbox.DropboxMetaInstaller", matchVendor, true},
+	}
+	for _, tt := range tests {
+		rule, ok := tt.m.match(tt.entry, matchRules)
+		if ok != tt.ok || (ok && rule != tt.rule) {
+			t.Errorf("%s: match(%q) = %v, %v; want %v, %v", tt.name, tt.entry, rule, ok, tt.rule, tt.ok)
+		}
+	}
+}
+
+func TestMatcherRespectsAllowedRules(t *testing.T) {
+	m := newMatcher(&AppInfo{Name: "TestApp"}, "com.test.app")
+
+	if _, ok := m.match("TestApp Helper", []matchRule{matchBundleID, matchBundlePrefix}); ok {
+		t.Error("name match should not count when only bundle rules are allowed")
+	}
+	if rule, ok := m.match("com.test.app.plist", []matchRule{matchName, matchBundleID}); !ok || rule != matchBundleID {
+		t.Errorf("expected strongest allowed rule, got %v, %v", rule, ok)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// An orphanGroup collects leftovers that appear to come from the same app
+// or vendor, none of which belong to an installed app.
+type orphanGroup struct {
+	Key   string
+	Items []Finding
+}
+
+func (g orphanGroup) size() int64 {
+	var total int64
+	for _, f := range g.Items {
+		total += f.Size
+	}
+	return total
+}
+
+// confidence is that of the group's strongest item. A group found by folder
+// names alone is low confidence and left out when removing "all".
+func (g orphanGroup) confidence() confidence {
+	c := confidenceLow
+	for _, f := range g.Items {
+		c = max(c, f.Confidence)
+	}
+	return c
+}
+
+// systemLeftovers are names macOS itself creates in the scanned folders.
+var systemLeftovers = map[string]bool{
+	"accounts": true, "addressbook": true, "animoji": true, "appstore": true,
+	"assistant": true, "assistants": true, "books": true, "calendars": true,
+	"callhistorydb": true, "callhistorytransactions": true, "clouddocs": true,
+	"cloudkit": true, "containermanager": true, "contextstoreagent": true,
+	"coreparsec": true, "crashreporter": true, "diagnosticreports": true,
+	"differentialprivacy": true, "diskimages": true, "dock": true,
+	"facetime": true, "familycircle": true, "fileprovider": true,
+	"findmy": true, "gamekit": true, "geoservices": true, "homekit": true,
+	"icloud": true, "idleassetsd": true, "itunes": true, "keyboardservices": true,
+	"keychains": true, "knowledge": true, "mail": true, "maps": true,
+	"messages": true, "metadata": true, "mobilesync": true, "music": true,
+	"news": true, "notes": true, "passkit": true, "photos": true,
+	"podcasts": true, "quicklook": true, "reminders": true, "safari": true,
+	"screentime": true, "sharing": true, "shortcuts": true, "siri": true,
+	"siritts": true, "spotlight": true, "stocks": true, "syncservices": true,
+	"tv": true, "voicememos": true, "weather": true,
+}
+
+// orphanKey infers who left name behind: the vendor of a bundle ID, the bundle
+// ID itself when its vendor is too generic, or the first word of a plain
+// folder or bundle name. Plain files are skipped because their name says
+// too little about where they came from.
+func orphanKey(name string, isDir bool) (string, matchRule, bool) {
+	if strings.HasPrefix(name, ".") {
+		return "", 0, false
+	}
+	base := stripLeftoverExtension(name)
+	if parts := strings.Split(strings.ToLower(base), "."); isReverseDNS(parts) {
+		if parts[1] == "apple" {
+			return "", 0, false
+		}
+		if v := vendorOf(base); v != "" {
+			return v, matchBundleID, true
+		}
+		return strings.Join(parts[:3], "."), matchBundleID, true
+	}
+	if !isDir {
+		return "", 0, false
+	}
+	tokens := tokenize(base)
+	if len(tokens) == 0 || tokens[0] == "apple" || systemLeftovers[strings.Join(tokens, "")] {
+		return "", 0, false
+	}
+	return tokens[0], matchName, true
+}
+
+// findOrphans indexes every scanner location and groups the entries that no
+// installed app claims.
+func findOrphans(ctx context.Context, installed []AppInfo) ([]orphanGroup, error) {
+	idx, err := buildLibraryIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([][]Finding, len(installed))
+	err = parallel(ctx, len(installed), func(i int) {
+		matched[i] = matchApp(idx, &installed[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	owned := map[string]bool{}
+	for _, findings := range matched {
+		for _, f := range findings {
+			owned[f.Path] = true
+		}
+	}
+
+	var findings []Finding
+	for _, s := range registeredScanners() {
+		for _, dir := range s.Locations() {
+			for _, entry := range idx.list(dir) {
+				path := filepath.Join(dir, entry.Name())
+				if owned[path] || excluded(path) || protectedItemReason(path) != "" {
+					continue
+				}
+				_, rule, ok := orphanKey(entry.Name(), entry.IsDir())
+				if !ok {
+					continue
+				}
+				f := newFinding(path, s.ID(), rule)
+				if rule == matchName {
+					// A plain name is too weak a guess to offer anything
+					// from a high-risk folder such as Fonts, and elsewhere
+					// it may just as well be a folder of macOS itself.
+					if s.Risk() == riskHigh {
+						continue
+					}
+					f.Confidence = confidenceLow
+				}
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	findings = normalizeFindings(findings)
+	if err := describeFindings(ctx, findings); err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]int{}
+	var groups []orphanGroup
+	for _, f := range findings {
+		info, err := os.Lstat(f.Path)
+		if err != nil {
+			continue
+		}
+		key, _, _ := orphanKey(filepath.Base(f.Path), info.IsDir())
+		i, ok := byKey[key]
+		if !ok {
+			i = len(groups)
+			byKey[key] = i
+			groups = append(groups, orphanGroup{Key: key})
+		}
+		groups[i].Items = append(groups[i].Items, f)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups, nil
+}
+
+func runOrphans(cmd *cobra.Command, args []string) {
+	installed, err := getInstalledApps()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	var groups []orphanGroup
+	interruptible(func(ctx context.Context) error {
+		groups, err = findOrphans(ctx, installed)
+		return err
+	})
+
+	if jsonOutput() {
+		writeJSON(newOrphansOutput(groups))
+		return
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No orphaned items found.")
+		return
+	}
+
+	fmt.Println("Orphaned items:")
+	fmt.Println("---------------")
+	for i, g := range groups {
+		note := ""
+		if g.confidence() == confidenceLow {
+			note = ", low confidence"
+		}
+		fmt.Printf("%d. %s (%d items, %s%s)\n", i+1, g.Key, len(g.Items), formatSize(g.size()), note)
+		for _, f := range g.Items {
+			fmt.Printf("  - %s (%s, %s)\n", f.Path, formatSize(f.Size), scannerByID(f.Category).Name())
+		}
+	}
+	if orphansList {
+		return
+	}
+
+	fmt.Print("\nEnter the groups to remove (e.g. 1,3-5), all (skips low confidence groups), or nothing to cancel: ")
+	reader := bufio.NewReader(os.Stdin)
+	line, _ := reader.ReadString('\n')
+	selected, err := selectOrphanGroups(groups, line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(selected) == 0 {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	journal := startJournal()
+	failed := false
+	for _, g := range selected {
+		for _, f := range g.Items {
+			if removeAndReport(journal, g.Key, f.Category, f.Path).Action == "failed" {
+				failed = true
+			}
+		}
+	}
+
+	if dryRun {
+		fmt.Println("\nDry run complete. No files were actually deleted.")
+	}
+	printUndoHint(journal)
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// selectOrphanGroups picks groups by number, e.g. "1,4,7-9", or all of them
+// but the low confidence ones, which must be picked by number.
+func selectOrphanGroups(groups []orphanGroup, line string) ([]orphanGroup, error) {
+	line = strings.TrimSpace(line)
+	if strings.EqualFold(line, "all") {
+		var selected []orphanGroup
+		for _, g := range groups {
+			if g.confidence() > confidenceLow {
+				selected = append(selected, g)
+			}
+		}
+		return selected, nil
+	}
+	indexes, err := parseSelection(line, len(groups))
+	if err != nil {
+		return nil, err
+	}
+	var selected []orphanGroup
+	for _, i := range indexes {
+		selected = append(selected, groups[i])
+	}
+	return selected, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOrphanKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		isDir bool
+		key   string
+		ok    bool
+	}{
+		{"com.getdropbox.dropbox.plist", false, "dropbox", true},
+		{"com.dropbox.DropboxUpdater.wake.plist", false, "dropbox", true},
+		{"com.github.Electron.savedState", true, "com.github.electron", true},
+		{"Dropbox", true, "dropbox", true},
+		{"GoogleChrome", true, "google", true},
+		{"com.apple.dock.plist", false, "", false},
+		{"CloudKit", true, "", false},
+		{"FaceTime", true, "", false},
+		{"CallHistoryTransactions", true, "", false},
+		{"AppleMediaServices", true, "", false},
+		{"Custom.ttf", false, "", false},
+		{".DS_Store", false, "", false},
+	}
+	for _, tt := range tests {
+		key, _, ok := orphanKey(tt.name, tt.isDir)
+		if key != tt.key || ok != tt.ok {
+			t.Errorf("orphanKey(%q) = %q, %v; want %q, %v", tt.name, key, ok, tt.key, tt.ok)
+		}
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	fs.createApp(t, "TestApp", "com.test.app")
+	fs.createPrefFile(t, "com.test.app", ".plist")
+	fs.createCachesDir(t, "TestApp")
+
+	gonePref := fs.createPrefFile(t, "com.gone.app", ".plist")
+	goneSupport := fs.createAppSupportDir(t, "GoneApp")
+	goneAgent := fs.createLaunchAgent(t, "com.gone.app.agent")
+	fs.createPrefFile(t, "com.apple.dock", ".plist")
+	fs.createFont(t, "Custom")
+	fs.createAppSupportDir(t, "FaceTime")
+	fs.createAppSupportDir(t, "CallHistoryTransactions")
+	fs.createAppSupportDir(t, "com.apple.sharedfilelist")
+	mystery := fs.createAppSupportDir(t, "Mystery Tool")
+
+	installed, err := getApplications(filepath.Join(fs.homeDir, "Applications"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	groups, err := findOrphans(context.Background(), installed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Key != "gone" || groups[1].Key != "mystery" {
+		t.Fatalf("expected groups for gone and mystery, got %+v", groups)
+	}
+	if c := groups[0].confidence(); c != confidenceHigh {
+		t.Errorf("expected gone to be high confidence, got %v", c)
+	}
+	if c := groups[1].confidence(); c != confidenceLow || groups[1].Items[0].Path != mystery {
+		t.Errorf("expected a low confidence group for %s, got %v %+v", mystery, c, groups[1].Items)
+	}
+
+	byPath := map[string]Finding{}
+	for _, f := range groups[0].Items {
+		byPath[f.Path] = f
+	}
+	for path, category := range map[string]string{
+		gonePref:    "associated-files",
+		goneSupport: "associated-files",
+		goneAgent:   "startup-items",
+	} {
+		f, ok := byPath[path]
+		if !ok {
+			t.Errorf("expected orphan %s, got %v", path, groups[0].Items)
+			continue
+		}
+		if f.Category != category {
+			t.Errorf("expected %s in %s, got %s", path, category, f.Category)
+		}
+	}
+	if len(byPath) != 3 {
+		t.Errorf("expected 3 orphaned items, got %v", groups[0].Items)
+	}
+	if groups[0].size() == 0 {
+		t.Error("expected group size to be computed")
+	}
+
+	selected, err := selectOrphanGroups(groups, "1\n")
+	if err != nil || len(selected) != 1 {
+		t.Errorf("expected group 1 to be selected, got %v, %v", selected, err)
+	}
+	selected, err = selectOrphanGroups(groups, "all")
+	if err != nil || len(selected) != 1 || selected[0].Key != "gone" {
+		t.Errorf("expected all to skip the low confidence group, got %v, %v", selected, err)
+	}
+	selected, err = selectOrphanGroups(groups, "2")
+	if err != nil || len(selected) != 1 || selected[0].Key != "mystery" {
+		t.Errorf("expected group 2 to be selectable by number, got %v, %v", selected, err)
+	}
+	if _, err := selectOrphanGroups(groups, "3"); err == nil {
+		t.Error("expected an error selecting a missing group")
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// outputSchemaVersion is bumped whenever a JSON document changes in a way
+// that could break consumers.
+const outputSchemaVersion = 5
+
+type appOutput struct {
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	Root         string `json:"root,omitempty"`
+	BundleID     string `json:"bundle_id,omitempty"`
+	DisplayName  string `json:"display_name,omitempty"`
+	Version      string `json:"version,omitempty"`
+	BuildVersion string `json:"build_version,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	SigningID    string `json:"signing_id,omitempty"`
+	TeamID       string `json:"team_id,omitempty"`
+}
+
+type inventoryOutput struct {
+	SchemaVersion int         `json:"schema_version"`
+	Apps          []appOutput `json:"apps"`
+}
+
+type categoryOutput struct {
+	Name  string          `json:"name"`
+	Title string          `json:"title"`
+	Risk  string          `json:"risk"`
+	Size  int64           `json:"size"`
+	Items []findingOutput `json:"items"`
+}
+
+type findingOutput struct {
+	Path       string    `json:"path"`
+	Rule       string    `json:"rule"`
+	Confidence string    `json:"confidence"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mtime"`
+	Type       string    `json:"type"`
+	Key        string    `json:"key,omitempty"`
+	Helper     string    `json:"helper,omitempty"`
+	SharedWith []string  `json:"shared_with,omitempty"`
+	Protected  string    `json:"protected,omitempty"`
+	Kept       bool      `json:"kept,omitempty"`
+}
+
+type scanOutput struct {
+	SchemaVersion int              `json:"schema_version"`
+	App           appOutput        `json:"app"`
+	Categories    []categoryOutput `json:"categories"`
+}
+
+type deleteResult struct {
+	Path     string `json:"path"`
+	Category string `json:"category"`
+	Action   string `json:"action"`
+	Reason   string `json:"reason,omitempty"`
+	Unloaded string `json:"unloaded,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type appDeleteOutput struct {
+	App         appOutput        `json:"app"`
+	Reclaimable int64            `json:"reclaimable"`
+	Categories  []categoryOutput `json:"categories"`
+	Results     []deleteResult   `json:"results"`
+}
+
+type deleteOutput struct {
+	SchemaVersion int               `json:"schema_version"`
+	DryRun        bool              `json:"dry_run"`
+	Permanent     bool              `json:"permanent"`
+	Reclaimable   int64             `json:"reclaimable"`
+	Session       string            `json:"session,omitempty"`
+	Apps          []appDeleteOutput `json:"apps"`
+}
+
+func (out deleteOutput) failed() bool {
+	for _, app := range out.Apps {
+		for _, r := range app.Results {
+			if r.Action == "failed" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type orphanItemOutput struct {
+	Category string `json:"category"`
+	findingOutput
+}
+
+type orphanGroupOutput struct {
+	Name       string             `json:"name"`
+	Size       int64              `json:"size"`
+	Confidence string             `json:"confidence"`
+	Items      []orphanItemOutput `json:"items"`
+}
+
+type orphansOutput struct {
+	SchemaVersion int                 `json:"schema_version"`
+	Groups        []orphanGroupOutput `json:"groups"`
+}
+
+func checkOutputFormat() error {
+	if outputFormat != "text" && outputFormat != "json" {
+		return fmt.Errorf("unknown output format %q (want text or json)", outputFormat)
+	}
+	return nil
+}
+
+func jsonOutput() bool {
+	return outputFormat == "json"
+}
+
+func writeJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newAppOutput(app AppInfo) appOutput {
+	return appOutput{
+		Name:         app.Name,
+		Path:         app.Path,
+		Root:         app.Root,
+		BundleID:     app.BundleID,
+		DisplayName:  app.DisplayName,
+		Version:      app.Version,
+		BuildVersion: app.BuildVersion,
+		Size:         app.Size,
+		SigningID:    app.SigningID,
+		TeamID:       app.TeamID,
+	}
+}
+
+func newInventoryOutput(apps []AppInfo) inventoryOutput {
+	out := inventoryOutput{SchemaVersion: outputSchemaVersion, Apps: []appOutput{}}
+	for _, app := range apps {
+		out.Apps = append(out.Apps, newAppOutput(app))
+	}
+	return out
+}
+
+func newFindingOutput(f Finding) findingOutput {
+	return findingOutput{
+		Path:       f.Path,
+		Rule:       f.Rule.String(),
+		Confidence: f.Confidence.String(),
+		Size:       f.Size,
+		ModTime:    f.ModTime,
+		Type:       f.Type,
+		Key:        f.Key,
+		Helper:     f.Helper,
+		SharedWith: f.SharedWith,
+		Protected:  f.Protected,
+		Kept:       f.kept(),
+	}
+}
+
+func newCategoryOutputs(app AppInfo) []categoryOutput {
+	var out []categoryOutput
+	for _, c := range app.categories() {
+		items := []findingOutput{}
+		for _, f := range c.Items {
+			items = append(items, newFindingOutput(f))
+		}
+		out = append(out, categoryOutput{Name: c.Key, Title: c.Title, Risk: c.Risk.String(), Size: c.size(), Items: items})
+	}
+	return out
+}
+
+func newScanOutput(app AppInfo) scanOutput {
+	return scanOutput{
+		SchemaVersion: outputSchemaVersion,
+		App:           newAppOutput(app),
+		Categories:    newCategoryOutputs(app),
+	}
+}
+
+func newDeleteOutput(apps []AppInfo, journal *journalSession) deleteOutput {
+	out := deleteOutput{
+		SchemaVersion: outputSchemaVersion,
+		DryRun:        dryRun,
+		Permanent:     permanent,
+		Reclaimable:   totalReclaimable(apps),
+		Apps:          []appDeleteOutput{},
+	}
+	for _, app := range apps {
+		out.Apps = append(out.Apps, appDeleteOutput{
+			App:         newAppOutput(app),
+			Reclaimable: app.reclaimable(),
+			Categories:  newCategoryOutputs(app),
+			Results:     []deleteResult{},
+		})
+	}
+	if journal != nil {
+		out.Session = journal.ID
+	}
+	return out
+}
+
+func newOrphansOutput(groups []orphanGroup) orphansOutput {
+	out := orphansOutput{SchemaVersion: outputSchemaVersion, Groups: []orphanGroupOutput{}}
+	for _, g := range groups {
+		group := orphanGroupOutput{Name: g.Key, Size: g.size(), Confidence: g.confidence().String(), Items: []orphanItemOutput{}}
+		for _, f := range g.Items {
+			group.Items = append(group.Items, orphanItemOutput{Category: f.Category, findingOutput: newFindingOutput(f)})
+		}
+		out.Groups = append(out.Groups, group)
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestScanOutputJSON(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	bundleID := "com.test.app"
+	appPath := fs.createApp(t, "TestApp", bundleID)
+	prefPath := fs.createPrefFile(t, bundleID, ".plist")
+
+	app := AppInfo{Name: "TestApp", Path: appPath}
+	if err := loadBundleInfo(&app); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scanAssociatedFiles(context.Background(), &app)
+
+	data, err := json.Marshal(newScanOutput(app))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc struct {
+		SchemaVersion int `json:"schema_version"`
+		App           struct {
+			Name     string `json:"name"`
+			BundleID string `json:"bundle_id"`
+		} `json:"app"`
+		Categories []struct {
+			Name  string `json:"name"`
+			Items []struct {
+				Path       string `json:"path"`
+				Rule       string `json:"rule"`
+				Confidence string `json:"confidence"`
+				Size       int64  `json:"size"`
+				Type       string `json:"type"`
+			} `json:"items"`
+		} `json:"categories"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if doc.SchemaVersion != outputSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", outputSchemaVersion, doc.SchemaVersion)
+	}
+	if doc.App.Name != "TestApp" || doc.App.BundleID != bundleID {
+		t.Errorf("unexpected app: %+v", doc.App)
+	}
+	if len(doc.Categories) != len(app.categories()) {
+		t.Fatalf("expected %d categories, got %d", len(app.categories()), len(doc.Categories))
+	}
+	if doc.Categories[0].Name != "associated-files" {
+		t.Errorf("expected associated-files first, got %s", doc.Categories[0].Name)
+	}
+	found := false
+	for _, item := range doc.Categories[0].Items {
+		if item.Path == prefPath {
+			found = true
+			if item.Rule != "bundle-id" || item.Confidence != "high" || item.Size < 4 || item.Type != "file" {
+				t.Errorf("unexpected finding: %+v", item)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected %s in associated files, got %v", prefPath, doc.Categories[0].Items)
+	}
+	for _, c := range doc.Categories {
+		if c.Items == nil {
+			t.Errorf("category %s should encode items as an empty array", c.Name)
+		}
+	}
+}
+
+func TestRemoveAndReportResults(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	outputFormat = "json"
+	defer func() { outputFormat = "text" }()
+
+	prefPath := fs.createPrefFile(t, "com.test.app", ".plist")
+
+	dryRun = true
+	result := removeAndReport(nil, "TestApp", "associated-files", prefPath)
+	dryRun = false
+	if result.Action != "would-trash" || result.Error != "" {
+		t.Errorf("unexpected dry run result: %+v", result)
+	}
+	if exists, _ := pathExists(prefPath); !exists {
+		t.Error("dry run should not remove anything")
+	}
+
+	result = removeAndReport(nil, "TestApp", "associated-files", prefPath)
+	if result.Action != "trashed" || result.Category != "associated-files" || result.Path != prefPath {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	result = removeAndReport(nil, "TestApp", "associated-files", prefPath)
+	if result.Action != "failed" || result.Error == "" {
+		t.Errorf("expected failure removing a missing path, got %+v", result)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// systemPath rebases an absolute path on the target system onto --root, so
+// an offline macOS volume mounted elsewhere can be inspected.
+func systemPath(path string) string {
+	if volumeRoot == "" {
+		return path
+	}
+	return filepath.Join(volumeRoot, path)
+}
+
+// homeDir is the home directory of the user being cleaned up: $HOME by
+// default, or /Users/<name> on the target volume when --user is given.
+func homeDir() string {
+	if targetUser != "" {
+		return systemPath(filepath.Join("/Users", targetUser))
+	}
+	return os.Getenv("HOME")
+}
+
+func homePath(elem ...string) string {
+	return filepath.Join(append([]string{homeDir()}, elem...)...)
+}
+
+// targetUID is the uid owning the target home directory, which on an
+// offline volume is not the uid zaap is running as.
+func targetUID() int {
+	if volumeRoot == "" && targetUser == "" {
+		return os.Getuid()
+	}
+	if info, err := os.Stat(homeDir()); err == nil {
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			return int(st.Uid)
+		}
+	}
+	return os.Getuid()
+}
+
+func checkTarget() error {
+	if volumeRoot != "" {
+		abs, err := filepath.Abs(volumeRoot)
+		if err != nil {
+			return err
+		}
+		volumeRoot = abs
+		info, err := os.Stat(volumeRoot)
+		if err != nil {
+			return fmt.Errorf("--root: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("--root: %s is not a directory", volumeRoot)
+		}
+		if targetUser == "" {
+			return fmt.Errorf("--user is required with --root")
+		}
+	}
+	if targetUser != "" {
+		if _, err := os.Stat(homeDir()); err != nil {
+			return fmt.Errorf("--user %s: %w", targetUser, err)
+		}
+	}
+	return nil
+}
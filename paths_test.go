@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSystemPathAndHomeDir(t *testing.T) {
+	os.Setenv("HOME", "/Users/current")
+
+	if got := systemPath("/Library/Fonts"); got != "/Library/Fonts" {
+		t.Errorf("expected unchanged path without --root, got %s", got)
+	}
+	if got := homeDir(); got != "/Users/current" {
+		t.Errorf("expected $HOME without --user, got %s", got)
+	}
+
+	volumeRoot, targetUser = "/mnt/mac", "bob"
+	defer func() { volumeRoot, targetUser = "", "" }()
+
+	if got := systemPath("/Library/Fonts"); got != "/mnt/mac/Library/Fonts" {
+		t.Errorf("expected rebased path, got %s", got)
+	}
+	if got := homePath("Library", "Caches"); got != "/mnt/mac/Users/bob/Library/Caches" {
+		t.Errorf("expected rebased home, got %s", got)
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	volume := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(volume, "Users", "bob"), 0755); err != nil {
+		t.Fatalf("failed to create home: %v", err)
+	}
+	defer func() { volumeRoot, targetUser = "", "" }()
+
+	volumeRoot, targetUser = volume, ""
+	if err := checkTarget(); err == nil {
+		t.Error("expected error when --root is given without --user")
+	}
+
+	volumeRoot, targetUser = volume, "alice"
+	if err := checkTarget(); err == nil {
+		t.Error("expected error for a user without a home folder")
+	}
+
+	volumeRoot, targetUser = filepath.Join(volume, "missing"), "bob"
+	if err := checkTarget(); err == nil {
+		t.Error("expected error for a missing root")
+	}
+
+	volumeRoot, targetUser = volume, "bob"
+	if err := checkTarget(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestScanOfflineVolume(t *testing.T) {
+	volume := t.TempDir()
+	home := filepath.Join(volume, "Users", "bob")
+	os.Setenv("HOME", t.TempDir())
+
+	dirs := []string{
+		filepath.Join(volume, "Applications", "TestApp.app", "Contents"),
+		filepath.Join(volume, "Library", "Fonts"),
+		filepath.Join(volume, "Library", "LaunchDaemons"),
+		filepath.Join(home, "Library", "Preferences"),
+		filepath.Join(home, "Library", "Caches", "com.test.app"),
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(volume, "Applications", "TestApp.app", "Contents", "Info.plist"): `<plist><dict><key>CFBundleIdentifier</key><string>com.test.app</string></dict></plist>`,
+		filepath.Join(volume, "Library", "Fonts", "TestApp.ttf"):                       "font",
+		filepath.Join(volume, "Library", "LaunchDaemons", "com.test.app.plist"):        "daemon",
+		filepath.Join(home, "Library", "Preferences", "com.test.app.plist"):            "prefs",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	volumeRoot, targetUser = volume, "bob"
+	defer func() { volumeRoot, targetUser = "", "" }()
+
+	apps, err := getInstalledApps()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(apps) != 1 || apps[0].BundleID != "com.test.app" {
+		t.Fatalf("expected TestApp on the volume, got %+v", apps)
+	}
+
+	app := apps[0]
+	scanAssociatedFiles(context.Background(), &app)
+
+	want := map[string]bool{
+		filepath.Join(home, "Library", "Preferences", "com.test.app.plist"):     false,
+		filepath.Join(home, "Library", "Caches", "com.test.app"):                false,
+		filepath.Join(volume, "Library", "Fonts", "TestApp.ttf"):                false,
+		filepath.Join(volume, "Library", "LaunchDaemons", "com.test.app.plist"): false,
+	}
+	for _, c := range app.categories() {
+		for _, item := range c.Items {
+			if _, ok := want[item.Path]; ok {
+				want[item.Path] = true
+			}
+		}
+	}
+	for path, found := range want {
+		if !found {
+			t.Errorf("expected to find %s", path)
+		}
+	}
+}
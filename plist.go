@@ -0,0 +1,723 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Property list values are decoded into plain Go types: map[string]any for
+// dictionaries, []any for arrays, string, int64, float64, bool, time.Time,
+// []byte for data and plistUID for keyed-archiver references.
+type plistUID uint64
+
+var errPlistFormat = errors.New("plist: invalid format")
+
+// plistEpoch is the reference date for binary plist dates.
+var plistEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func readPlist(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePlist(data)
+}
+
+func readPlistDict(path string) (map[string]any, error) {
+	v, err := readPlist(path)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("plist: %s: top-level value is not a dictionary", path)
+	}
+	return dict, nil
+}
+
+// parsePlist decodes an XML, binary (bplist00) or OpenStep property list.
+func parsePlist(data []byte) (any, error) {
+	if bytes.HasPrefix(data, []byte("bplist00")) {
+		return parseBinaryPlist(data)
+	}
+	text := bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text = bytes.TrimLeft(text, " \t\r\n")
+	if bytes.HasPrefix(text, []byte("<?xml")) || bytes.HasPrefix(text, []byte("<!")) || bytes.HasPrefix(text, []byte("<plist")) {
+		return parseXMLPlist(text)
+	}
+	return parseOpenStepPlist(text)
+}
+
+func plistString(dict map[string]any, key string) string {
+	s, _ := dict[key].(string)
+	return s
+}
+
+// XML
+
+func parseXMLPlist(data []byte) (any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("plist: no value found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == "plist" {
+			continue
+		}
+		return decodeXMLValue(dec, start)
+	}
+}
+
+func decodeXMLValue(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]any{}
+		for {
+			tok, err := nextXMLElement(dec)
+			if err != nil {
+				return nil, err
+			}
+			if tok == nil {
+				return dict, nil
+			}
+			if tok.Name.Local != "key" {
+				return nil, fmt.Errorf("plist: expected <key>, got <%s>", tok.Name.Local)
+			}
+			key, err := xmlText(dec)
+			if err != nil {
+				return nil, err
+			}
+			valueStart, err := nextXMLElement(dec)
+			if err != nil {
+				return nil, err
+			}
+			if valueStart == nil {
+				return nil, fmt.Errorf("plist: missing value for key %q", key)
+			}
+			value, err := decodeXMLValue(dec, *valueStart)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = value
+		}
+	case "array":
+		array := []any{}
+		for {
+			tok, err := nextXMLElement(dec)
+			if err != nil {
+				return nil, err
+			}
+			if tok == nil {
+				return array, nil
+			}
+			value, err := decodeXMLValue(dec, *tok)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+	case "string", "key":
+		return xmlText(dec)
+	case "integer":
+		text, err := xmlText(dec)
+		if err != nil {
+			return nil, err
+		}
+		text = strings.TrimSpace(text)
+		if n, err := strconv.ParseInt(text, 0, 64); err == nil {
+			return n, nil
+		}
+		n, err := strconv.ParseUint(text, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid integer %q", text)
+		}
+		return int64(n), nil
+	case "real":
+		text, err := xmlText(dec)
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid real %q", text)
+		}
+		return f, nil
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		return start.Name.Local == "true", nil
+	case "date":
+		text, err := xmlText(dec)
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid date %q", text)
+		}
+		return t, nil
+	case "data":
+		text, err := xmlText(dec)
+		if err != nil {
+			return nil, err
+		}
+		text = strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+				return -1
+			}
+			return r
+		}, text)
+		b, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid data: %w", err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("plist: unknown element <%s>", start.Name.Local)
+}
+
+// nextXMLElement returns the next start element, or nil when the enclosing
+// element ends.
+func nextXMLElement(dec *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("plist: unexpected end of document")
+			}
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+func xmlText(dec *xml.Decoder) (string, error) {
+	var sb strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("plist: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.EndElement:
+			return sb.String(), nil
+		case xml.StartElement:
+			return "", fmt.Errorf("plist: unexpected <%s> in text element", t.Name.Local)
+		}
+	}
+}
+
+// Binary
+
+type binaryPlist struct {
+	data       []byte
+	offsets    []uint64
+	refSize    int
+	inProgress map[uint64]bool
+}
+
+func parseBinaryPlist(data []byte) (any, error) {
+	if len(data) < 8+32 {
+		return nil, errPlistFormat
+	}
+	trailer := data[len(data)-32:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:])
+	topObject := binary.BigEndian.Uint64(trailer[16:])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:])
+
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 {
+		return nil, errPlistFormat
+	}
+	if numObjects == 0 || topObject >= numObjects {
+		return nil, errPlistFormat
+	}
+	tableEnd := uint64(len(data) - 32)
+	if tableOffset < 8 || tableOffset > tableEnd || numObjects > (tableEnd-tableOffset)/uint64(offsetSize) {
+		return nil, errPlistFormat
+	}
+
+	p := &binaryPlist{
+		data:       data[:tableOffset],
+		offsets:    make([]uint64, numObjects),
+		refSize:    refSize,
+		inProgress: map[uint64]bool{},
+	}
+	for i := range p.offsets {
+		start := tableOffset + uint64(i*offsetSize)
+		p.offsets[i] = readSizedUint(data[start : start+uint64(offsetSize)])
+	}
+	return p.object(topObject)
+}
+
+func readSizedUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+func (p *binaryPlist) object(ref uint64) (any, error) {
+	if ref >= uint64(len(p.offsets)) {
+		return nil, fmt.Errorf("plist: object reference %d out of range", ref)
+	}
+	if p.inProgress[ref] {
+		return nil, fmt.Errorf("plist: cyclic object reference %d", ref)
+	}
+	p.inProgress[ref] = true
+	defer delete(p.inProgress, ref)
+
+	off := p.offsets[ref]
+	if off < 8 || off >= uint64(len(p.data)) {
+		return nil, fmt.Errorf("plist: object offset %d out of range", off)
+	}
+	marker := p.data[off]
+	kind, info := marker>>4, marker&0x0f
+	off++
+
+	switch kind {
+	case 0x0:
+		switch info {
+		case 0x8:
+			return false, nil
+		case 0x9:
+			return true, nil
+		case 0x0, 0xf:
+			return nil, nil
+		}
+	case 0x1:
+		b, err := p.bytes(off, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > 8 {
+			b = b[len(b)-8:]
+		}
+		return int64(readSizedUint(b)), nil
+	case 0x2:
+		b, err := p.bytes(off, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		switch len(b) {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+		}
+	case 0x3:
+		b, err := p.bytes(off, 8)
+		if err != nil {
+			return nil, err
+		}
+		secs := math.Float64frombits(binary.BigEndian.Uint64(b))
+		whole, frac := math.Modf(secs)
+		return plistEpoch.Add(time.Duration(whole) * time.Second).Add(time.Duration(frac * float64(time.Second))), nil
+	case 0x4:
+		n, off, err := p.count(info, off)
+		if err != nil {
+			return nil, err
+		}
+		b, err := p.bytes(off, n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0x5:
+		n, off, err := p.count(info, off)
+		if err != nil {
+			return nil, err
+		}
+		b, err := p.bytes(off, n)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case 0x6:
+		n, off, err := p.count(info, off)
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(p.data))/2 {
+			return nil, fmt.Errorf("plist: string length %d overruns data", n)
+		}
+		b, err := p.bytes(off, n*2)
+		if err != nil {
+			return nil, err
+		}
+		units := make([]uint16, n)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(b[i*2:])
+		}
+		return string(utf16.Decode(units)), nil
+	case 0x8:
+		b, err := p.bytes(off, uint64(info)+1)
+		if err != nil {
+			return nil, err
+		}
+		return plistUID(readSizedUint(b)), nil
+	case 0xa, 0xc:
+		n, off, err := p.count(info, off)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.refs(off, n)
+		if err != nil {
+			return nil, err
+		}
+		array := make([]any, 0, len(refs))
+		for _, r := range refs {
+			v, err := p.object(r)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, v)
+		}
+		return array, nil
+	case 0xd:
+		n, off, err := p.count(info, off)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := p.refs(off, n*2)
+		if err != nil {
+			return nil, err
+		}
+		dict := make(map[string]any, n)
+		for i := uint64(0); i < n; i++ {
+			k, err := p.object(refs[i])
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("plist: dictionary key is %T, not a string", k)
+			}
+			v, err := p.object(refs[n+i])
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = v
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("plist: unknown object marker 0x%02x", marker)
+}
+
+func (p *binaryPlist) bytes(off, n uint64) ([]byte, error) {
+	if off > uint64(len(p.data)) || n > uint64(len(p.data))-off {
+		return nil, fmt.Errorf("plist: object at %d overruns data", off)
+	}
+	return p.data[off : off+n], nil
+}
+
+// count decodes an object length, which is either stored in the marker or,
+// for 0xf, as a following integer object.
+func (p *binaryPlist) count(info byte, off uint64) (uint64, uint64, error) {
+	if info != 0xf {
+		return uint64(info), off, nil
+	}
+	b, err := p.bytes(off, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b[0]>>4 != 0x1 {
+		return 0, 0, fmt.Errorf("plist: invalid length marker 0x%02x", b[0])
+	}
+	size := uint64(1) << (b[0] & 0x0f)
+	nb, err := p.bytes(off+1, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	// No object can hold more elements than the file has bytes, and the
+	// cap keeps n*2 and n*refSize from overflowing.
+	n := readSizedUint(nb)
+	if n > uint64(len(p.data)) {
+		return 0, 0, fmt.Errorf("plist: object count %d overruns data", n)
+	}
+	return n, off + 1 + size, nil
+}
+
+func (p *binaryPlist) refs(off, n uint64) ([]uint64, error) {
+	if n > uint64(len(p.data))/uint64(p.refSize) {
+		return nil, fmt.Errorf("plist: object count %d overruns data", n)
+	}
+	b, err := p.bytes(off, n*uint64(p.refSize))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, n)
+	for i := range refs {
+		refs[i] = readSizedUint(b[i*p.refSize : (i+1)*p.refSize])
+	}
+	return refs, nil
+}
+
+// OpenStep
+
+type openStepParser struct {
+	data []byte
+	pos  int
+}
+
+func parseOpenStepPlist(data []byte) (any, error) {
+	p := &openStepParser{data: data}
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, fmt.Errorf("plist: no value found")
+	}
+
+	// A .strings style file is a dictionary without the enclosing braces.
+	if c := p.data[p.pos]; c != '{' && c != '(' && c != '<' {
+		save := p.pos
+		if _, err := p.parseString(); err == nil {
+			p.skipSpace()
+			if p.pos < len(p.data) && p.data[p.pos] == '=' {
+				p.pos = save
+				return p.parseDictBody(0)
+			}
+		}
+		p.pos = save
+	}
+
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.data) {
+		return nil, p.errorf("unexpected trailing data")
+	}
+	return v, nil
+}
+
+func (p *openStepParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("plist: offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *openStepParser) skipSpace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.pos++
+		case c == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '/':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '*':
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				p.pos = len(p.data)
+				return
+			}
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func (p *openStepParser) parseValue() (any, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of data")
+	}
+	switch p.data[p.pos] {
+	case '{':
+		p.pos++
+		return p.parseDictBody('}')
+	case '(':
+		p.pos++
+		array := []any{}
+		for {
+			p.skipSpace()
+			if p.pos >= len(p.data) {
+				return nil, p.errorf("unterminated array")
+			}
+			if p.data[p.pos] == ')' {
+				p.pos++
+				return array, nil
+			}
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, v)
+			p.skipSpace()
+			if p.pos < len(p.data) && p.data[p.pos] == ',' {
+				p.pos++
+			} else if p.pos >= len(p.data) || p.data[p.pos] != ')' {
+				return nil, p.errorf("expected ',' or ')' in array")
+			}
+		}
+	case '<':
+		p.pos++
+		end := bytes.IndexByte(p.data[p.pos:], '>')
+		if end < 0 {
+			return nil, p.errorf("unterminated data")
+		}
+		digits := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+				return -1
+			}
+			return r
+		}, string(p.data[p.pos:p.pos+end]))
+		b, err := hex.DecodeString(digits)
+		if err != nil {
+			return nil, p.errorf("invalid data: %v", err)
+		}
+		p.pos += end + 1
+		return b, nil
+	}
+	return p.parseString()
+}
+
+// parseDictBody parses "key = value;" pairs up to the closing delimiter, or
+// to the end of input when closing is 0.
+func (p *openStepParser) parseDictBody(closing byte) (any, error) {
+	dict := map[string]any{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			if closing == 0 {
+				return dict, nil
+			}
+			return nil, p.errorf("unterminated dictionary")
+		}
+		if closing != 0 && p.data[p.pos] == closing {
+			p.pos++
+			return dict, nil
+		}
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '=' {
+			return nil, p.errorf("expected '=' after key %q", key)
+		}
+		p.pos++
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != ';' {
+			return nil, p.errorf("expected ';' after value for key %q", key)
+		}
+		p.pos++
+		dict[key] = v
+	}
+}
+
+func isOpenStepUnquoted(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.IndexByte("_$+/:.-", c) >= 0
+}
+
+func (p *openStepParser) parseString() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return "", p.errorf("unexpected end of data")
+	}
+	quote := p.data[p.pos]
+	if quote != '"' && quote != '\'' {
+		start := p.pos
+		for p.pos < len(p.data) && isOpenStepUnquoted(p.data[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", p.errorf("unexpected character %q", p.data[p.pos])
+		}
+		return string(p.data[start:p.pos]), nil
+	}
+
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.data) {
+				return "", p.errorf("unterminated escape")
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'a':
+				sb.WriteByte('\a')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'v':
+				sb.WriteByte('\v')
+			case 'U', 'u':
+				end := p.pos
+				for end < len(p.data) && end-p.pos < 4 && strings.IndexByte("0123456789abcdefABCDEF", p.data[end]) >= 0 {
+					end++
+				}
+				n, err := strconv.ParseUint(string(p.data[p.pos:end]), 16, 16)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+				sb.WriteRune(rune(n))
+				p.pos = end
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				end := p.pos - 1
+				for end < len(p.data) && end-(p.pos-1) < 3 && p.data[end] >= '0' && p.data[end] <= '7' {
+					end++
+				}
+				n, _ := strconv.ParseUint(string(p.data[p.pos-1:end]), 8, 8)
+				sb.WriteByte(byte(n))
+				p.pos = end
+			default:
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
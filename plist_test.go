@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// encodeBinaryPlist builds a bplist00 document from maps, slices, strings,
+// ints, floats and bools so tests do not depend on plutil.
+func encodeBinaryPlist(t *testing.T, v any) []byte {
+	t.Helper()
+
+	var objects [][]byte
+	var add func(v any) uint64
+	writeCount := func(buf *bytes.Buffer, kind byte, n int) {
+		if n < 15 {
+			buf.WriteByte(kind<<4 | byte(n))
+			return
+		}
+		buf.WriteByte(kind<<4 | 0xf)
+		buf.WriteByte(0x13)
+		binary.Write(buf, binary.BigEndian, uint64(n))
+	}
+	add = func(v any) uint64 {
+		idx := uint64(len(objects))
+		objects = append(objects, nil)
+		var buf bytes.Buffer
+		switch x := v.(type) {
+		case bool:
+			if x {
+				buf.WriteByte(0x09)
+			} else {
+				buf.WriteByte(0x08)
+			}
+		case int:
+			buf.WriteByte(0x13)
+			binary.Write(&buf, binary.BigEndian, int64(x))
+		case float64:
+			buf.WriteByte(0x23)
+			binary.Write(&buf, binary.BigEndian, math.Float64bits(x))
+		case string:
+			ascii := true
+			for _, r := range x {
+				if r > 0x7f {
+					ascii = false
+				}
+			}
+			if ascii {
+				writeCount(&buf, 0x5, len(x))
+				buf.WriteString(x)
+			} else {
+				units := utf16.Encode([]rune(x))
+				writeCount(&buf, 0x6, len(units))
+				binary.Write(&buf, binary.BigEndian, units)
+			}
+		case []byte:
+			writeCount(&buf, 0x4, len(x))
+			buf.Write(x)
+		case []any:
+			refs := make([]uint64, len(x))
+			for i, e := range x {
+				refs[i] = add(e)
+			}
+			writeCount(&buf, 0xa, len(refs))
+			for _, r := range refs {
+				buf.WriteByte(byte(r))
+			}
+		case map[string]any:
+			keys := make([]string, 0, len(x))
+			for k := range x {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			var keyRefs, valueRefs []uint64
+			for _, k := range keys {
+				keyRefs = append(keyRefs, add(k))
+				valueRefs = append(valueRefs, add(x[k]))
+			}
+			writeCount(&buf, 0xd, len(keys))
+			for _, r := range append(keyRefs, valueRefs...) {
+				buf.WriteByte(byte(r))
+			}
+		default:
+			t.Fatalf("unsupported type %T", v)
+		}
+		objects[idx] = buf.Bytes()
+		return idx
+	}
+	add(v)
+	if len(objects) > 255 {
+		t.Fatalf("too many objects for one-byte refs: %d", len(objects))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("bplist00")
+	offsets := make([]uint16, len(objects))
+	for i, obj := range objects {
+		offsets[i] = uint16(out.Len())
+		out.Write(obj)
+	}
+	tableOffset := out.Len()
+	binary.Write(&out, binary.BigEndian, offsets)
+	out.Write(make([]byte, 6))
+	out.WriteByte(2)
+	out.WriteByte(1)
+	binary.Write(&out, binary.BigEndian, uint64(len(objects)))
+	binary.Write(&out, binary.BigEndian, uint64(0))
+	binary.Write(&out, binary.BigEndian, uint64(tableOffset))
+	return out.Bytes()
+}
+
+func TestParsePlistXML(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>com.test.app</string>
+	<key>Count</key>
+	<integer>42</integer>
+	<key>Ratio</key>
+	<real>1.5</real>
+	<key>Enabled</key>
+	<true/>
+	<key>Disabled</key>
+	<false/>
+	<key>Created</key>
+	<date>2024-01-02T03:04:05Z</date>
+	<key>Blob</key>
+	<data>
+	aGVs
+	bG8=
+	</data>
+	<key>Types</key>
+	<array>
+		<dict>
+			<key>CFBundleTypeName</key>
+			<string>Text &amp; Notes</string>
+		</dict>
+		<string></string>
+	</array>
+</dict>
+</plist>`)
+
+	v, err := parsePlist(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"CFBundleIdentifier": "com.test.app",
+		"Count":              int64(42),
+		"Ratio":              1.5,
+		"Enabled":            true,
+		"Disabled":           false,
+		"Created":            time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"Blob":               []byte("hello"),
+		"Types": []any{
+			map[string]any{"CFBundleTypeName": "Text & Notes"},
+			"",
+		},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %#v, want %#v", v, want)
+	}
+}
+
+func TestParsePlistBinary(t *testing.T) {
+	in := map[string]any{
+		"CFBundleIdentifier":         "com.test.app",
+		"CFBundleShortVersionString": "2.3",
+		"CFBundleDisplayName":        "Tëst App",
+		"Count":                      7,
+		"Ratio":                      0.25,
+		"Enabled":                    true,
+		"Blob":                       []byte{0xde, 0xad},
+		"LongString":                 "this string is long enough to need an extended length marker",
+		"Schemes":                    []any{"test", "testapp"},
+	}
+
+	v, err := parsePlist(encodeBinaryPlist(t, in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"CFBundleIdentifier":         "com.test.app",
+		"CFBundleShortVersionString": "2.3",
+		"CFBundleDisplayName":        "Tëst App",
+		"Count":                      int64(7),
+		"Ratio":                      0.25,
+		"Enabled":                    true,
+		"Blob":                       []byte{0xde, 0xad},
+		"LongString":                 "this string is long enough to need an extended length marker",
+		"Schemes":                    []any{"test", "testapp"},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %#v, want %#v", v, want)
+	}
+}
+
+func TestParsePlistOpenStep(t *testing.T) {
+	data := []byte(`// Old-style Info.plist
+{
+	CFBundleIdentifier = com.test.app;
+	CFBundleName = "Test App";
+	/* nested values */
+	CFBundleURLTypes = (
+		{ CFBundleURLSchemes = (test, "test-app"); },
+	);
+	Escaped = "line\none \"quoted\" \U00e9";
+	Blob = <68656c 6c6f>;
+}`)
+
+	v, err := parsePlist(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"CFBundleIdentifier": "com.test.app",
+		"CFBundleName":       "Test App",
+		"CFBundleURLTypes": []any{
+			map[string]any{"CFBundleURLSchemes": []any{"test", "test-app"}},
+		},
+		"Escaped": "line\none \"quoted\" é",
+		"Blob":    []byte("hello"),
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %#v, want %#v", v, want)
+	}
+
+	stringsFile, err := parsePlist([]byte(`"CFBundleName" = "Test";` + "\n" + `NSHumanReadableCopyright = "(c) Test";`))
+	if err != nil {
+		t.Fatalf("unexpected error parsing strings file: %v", err)
+	}
+	if d := stringsFile.(map[string]any); d["CFBundleName"] != "Test" || d["NSHumanReadableCopyright"] != "(c) Test" {
+		t.Errorf("unexpected strings file result: %#v", stringsFile)
+	}
+}
+
+func TestParsePlistMalformed(t *testing.T) {
+	valid := encodeBinaryPlist(t, map[string]any{"a": "b"})
+	truncated := append([]byte(nil), valid...)
+	// Point the offset table beyond the end of the file.
+	binary.BigEndian.PutUint64(truncated[len(truncated)-8:], uint64(len(truncated)))
+
+	// A single object whose extended length is 2^63, so n*2 wraps to 0.
+	hugeCount := func(marker byte) []byte {
+		var b bytes.Buffer
+		b.WriteString("bplist00")
+		b.WriteByte(marker)
+		b.WriteByte(0x13)
+		binary.Write(&b, binary.BigEndian, uint64(1)<<63)
+		table := b.Len()
+		b.WriteByte(8)
+		b.Write(make([]byte, 6))
+		b.WriteByte(1)
+		b.WriteByte(1)
+		binary.Write(&b, binary.BigEndian, uint64(1))
+		binary.Write(&b, binary.BigEndian, uint64(0))
+		binary.Write(&b, binary.BigEndian, uint64(table))
+		return b.Bytes()
+	}
+
+	inputs := map[string][]byte{
+		"huge utf16 string": hugeCount(0x6f),
+		"huge dict":         hugeCount(0xdf),
+		"empty":             {},
+		"bad xml":           []byte(`<?xml version="1.0"?><plist><dict><key>a</key></dict></plist>`),
+		"unknown element":   []byte(`<plist><color>red</color></plist>`),
+		"short binary":      []byte("bplist00"),
+		"bad binary table":  truncated,
+		"unterminated dict": []byte(`{ a = b;`),
+		"missing semicolon": []byte(`{ a = b }`),
+	}
+	for name, data := range inputs {
+		if _, err := parsePlist(data); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestGetBundleIDBinaryPlist(t *testing.T) {
+	fs := newTestFS(t)
+
+	appPath := filepath.Join(fs.homeDir, "Applications", "Binary.app")
+	if err := os.MkdirAll(filepath.Join(appPath, "Contents"), 0755); err != nil {
+		t.Fatalf("failed to create app contents: %v", err)
+	}
+	data := encodeBinaryPlist(t, map[string]any{"CFBundleIdentifier": "com.test.binary"})
+	if err := os.WriteFile(filepath.Join(appPath, "Contents", "Info.plist"), data, 0644); err != nil {
+		t.Fatalf("failed to write Info.plist: %v", err)
+	}
+
+	if got := getBundleID(appPath); got != "com.test.binary" {
+		t.Errorf("expected bundle ID com.test.binary, got %s", got)
+	}
+}
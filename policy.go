@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// trustedPaths are apps outside the standard folders that the user pointed
+// zaap at by path. Only the bundles themselves are trusted, not the folders
+// holding them.
+var trustedPaths []string
+
+func trustPath(path string) {
+	if path != "" && !slices.Contains(trustedPaths, path) {
+		trustedPaths = append(trustedPaths, path)
+	}
+}
+
+// knownRoots are the folders zaap may delete from: the application folders
+// and every scanner location.
+func knownRoots() []string {
+	roots := appDirs
+	if len(roots) == 0 {
+		roots = defaultAppRoots()
+	}
+	roots = slices.Clone(roots)
+	for _, s := range registeredScanners() {
+		roots = append(roots, s.Locations()...)
+	}
+	return roots
+}
+
+// checkRemovable is the last line of defence before anything is deleted.
+// It only allows paths strictly inside a known root, and only if no
+// symlink below that root leads somewhere else.
+func checkRemovable(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{"/", systemPath("/"), homeDir(), homePath("Library"), systemPath("/Library"), systemPath("/Users")} {
+		if abs == filepath.Clean(dir) {
+			return fmt.Errorf("refusing to delete %s: it is a top-level folder", abs)
+		}
+	}
+
+	if excluded(abs) {
+		return fmt.Errorf("refusing to delete %s: it is excluded in the zaap configuration", abs)
+	}
+
+	root := ""
+	for _, r := range knownRoots() {
+		r, err := filepath.Abs(r)
+		if err != nil {
+			continue
+		}
+		if abs == r {
+			return fmt.Errorf("refusing to delete %s: it is a folder zaap searches", abs)
+		}
+		if isUnder(abs, r) && len(r) > len(root) {
+			root = r
+		}
+	}
+	if root == "" && (slices.Contains(trustedPaths, abs) || isKnownLeftover(abs)) {
+		root = filepath.Dir(abs)
+	}
+	if root == "" {
+		return fmt.Errorf("refusing to delete %s: it is outside the folders zaap cleans up", abs)
+	}
+
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return fmt.Errorf("refusing to delete %s: %w", abs, err)
+	}
+	if want := filepath.Join(resolvedRoot, strings.TrimPrefix(abs, root)); resolved != want {
+		return fmt.Errorf("refusing to delete %s: it leads to %s through a symlink", abs, resolved)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckRemovable(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	prefPath := fs.createPrefFile(t, "com.test.app", ".plist")
+	appPath := fs.createApp(t, "TestApp", "com.test.app")
+	outside := filepath.Join(fs.rootDir, "outside")
+	if err := os.MkdirAll(outside, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "data"), []byte("keep"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	link := filepath.Join(fs.homeDir, "Library", "Caches", "linked")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatalf("failed to symlink: %v", err)
+	}
+	linkedSupport := filepath.Join(fs.homeDir, "Library", "Application Support", "Linked")
+	if err := os.Symlink(outside, linkedSupport); err != nil {
+		t.Fatalf("failed to symlink: %v", err)
+	}
+
+	allowed := []string{
+		prefPath,
+		appPath,
+		filepath.Join(appPath, "Contents"),
+	}
+	for _, path := range allowed {
+		if err := checkRemovable(path); err != nil {
+			t.Errorf("expected %s to be removable, got %v", path, err)
+		}
+	}
+
+	refused := map[string]string{
+		"/":                                  "top-level",
+		fs.homeDir:                           "top-level",
+		filepath.Join(fs.homeDir, "Library"): "top-level",
+		filepath.Join(fs.homeDir, "Library", "Preferences"): "searches",
+		filepath.Join(fs.homeDir, "Applications"):           "searches",
+		filepath.Join(fs.homeDir, "Documents", "x"):         "outside",
+		outside:                              "outside",
+		link:                                 "symlink",
+		filepath.Join(linkedSupport, "data"): "symlink",
+		filepath.Join(fs.homeDir, "Library", "Preferences", "..", "..", "Documents"): "outside",
+	}
+	for path, want := range refused {
+		err := checkRemovable(path)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %s to be refused (%s), got %v", path, want, err)
+		}
+	}
+}
+
+func TestRemoveAndReportRefusesUnsafePaths(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	outputFormat = "json"
+	defer func() { outputFormat = "text" }()
+
+	outside := filepath.Join(fs.rootDir, "important")
+	if err := os.WriteFile(outside, []byte("keep"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	for _, dry := range []bool{true, false} {
+		dryRun = dry
+		result := removeAndReport(nil, "TestApp", "associated-files", outside)
+		dryRun = false
+		if result.Action != "failed" || !strings.Contains(result.Error, "refusing") {
+			t.Errorf("dry run %v: expected a refusal, got %+v", dry, result)
+		}
+	}
+	if exists, _ := pathExists(outside); !exists {
+		t.Error("refused path should not be removed")
+	}
+}
+
+func TestTrustPathAllowsOnlyAppGivenByPath(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+	defer func() { trustedPaths = nil }()
+
+	downloads := filepath.Join(fs.rootDir, "Downloads")
+	appPath := filepath.Join(downloads, "Foo.app")
+	if err := os.MkdirAll(appPath, 0755); err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	if err := checkRemovable(appPath); err == nil {
+		t.Fatal("expected an app outside the known roots to be refused")
+	}
+	sibling := filepath.Join(downloads, "Other.app")
+	if err := os.MkdirAll(sibling, 0755); err != nil {
+		t.Fatalf("failed to create sibling: %v", err)
+	}
+
+	trustPath(appPath)
+	if err := checkRemovable(appPath); err != nil {
+		t.Errorf("expected the trusted app to be allowed, got %v", err)
+	}
+	for _, path := range []string{sibling, filepath.Join(appPath, "..", "Other.app"), downloads} {
+		if err := checkRemovable(path); err == nil {
+			t.Errorf("expected %s next to the trusted app to be refused", path)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sealedDirs live on the read-only system volume of the target system.
+// /usr/local is the exception and is handled in onSealedVolume.
+var sealedDirs = []string{"/System", "/bin", "/sbin", "/usr", "/Library/Apple"}
+
+// protectionReason explains why app must not be deleted without
+// --force-protected, or returns "" if it may be.
+func protectionReason(app AppInfo) string {
+	id := strings.ToLower(app.BundleID)
+	switch {
+	case strings.HasPrefix(id, "com.apple."):
+		return "it is part of macOS"
+	case app.Path != "" && onSealedVolume(app.Path):
+		return "it is on the sealed system volume"
+	case app.Path != "" && runningFrom(app.Path):
+		return "zaap is running from it"
+	case id != "" && id == strings.ToLower(defaultBrowserID()):
+		return "it is the default web browser"
+	case id != "" && id == strings.ToLower(currentTerminalID()):
+		return "zaap is running in it"
+	case configProtected(app):
+		return "it is protected in the zaap configuration"
+	}
+	return ""
+}
+
+// protectedItemReason is the finding-level counterpart of protectionReason.
+func protectedItemReason(path string) string {
+	switch {
+	case strings.HasPrefix(strings.ToLower(filepath.Base(path)), "com.apple."):
+		return "part of macOS"
+	case onSealedVolume(path):
+		return "on the sealed system volume"
+	}
+	return ""
+}
+
+// markProtected records which of app's findings belong to the system.
+func markProtected(app *AppInfo) {
+	for i := range app.Findings {
+		app.Findings[i].Protected = protectedItemReason(app.Findings[i].Path)
+	}
+}
+
+// checkProtected refuses to go on if any target is protected, unless
+// --force-protected is given.
+func checkProtected(targets []AppInfo) {
+	if forceProtected {
+		return
+	}
+	refused := false
+	for _, app := range targets {
+		if reason := protectionReason(app); reason != "" {
+			fmt.Fprintf(os.Stderr, "Refusing to delete %s: %s.\n", app.Name, reason)
+			refused = true
+		}
+	}
+	if refused {
+		fmt.Fprintln(os.Stderr, "Use --force-protected if you are sure.")
+		os.Exit(1)
+	}
+}
+
+func onSealedVolume(path string) bool {
+	candidates := []string{path}
+	// /Applications/Safari.app and friends are symlinks into /System.
+	if target, err := os.Readlink(path); err == nil {
+		if filepath.IsAbs(target) {
+			candidates = append(candidates, systemPath(target))
+		} else {
+			candidates = append(candidates, filepath.Join(filepath.Dir(path), target))
+		}
+	}
+	for _, p := range candidates {
+		if isUnder(p, systemPath("/usr/local")) {
+			continue
+		}
+		for _, dir := range sealedDirs {
+			if isUnder(p, systemPath(dir)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runningFrom reports whether the zaap executable lives inside dir.
+func runningFrom(dir string) bool {
+	exe, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return isUnder(exe, dir)
+}
+
+// defaultBrowserID reads the https handler from the target user's
+// LaunchServices preferences.
+func defaultBrowserID() string {
+	prefs, err := readPlistDict(homePath("Library", "Preferences", "com.apple.LaunchServices", "com.apple.launchservices.secure.plist"))
+	if err != nil {
+		return ""
+	}
+	for _, scheme := range []string{"https", "http"} {
+		for _, v := range plistArray(prefs, "LSHandlers") {
+			h, ok := v.(map[string]any)
+			if ok && plistString(h, "LSHandlerURLScheme") == scheme {
+				if id := plistString(h, "LSHandlerRoleAll"); id != "" {
+					return id
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// currentTerminalID is the bundle ID of the app zaap was started from,
+// which macOS passes down in __CFBundleIdentifier. It means nothing for an
+// offline volume.
+func currentTerminalID() string {
+	if volumeRoot != "" {
+		return ""
+	}
+	return os.Getenv("__CFBundleIdentifier")
+}
+
+func isUnder(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProtectionReason(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+	os.Unsetenv("__CFBundleIdentifier")
+
+	prefsDir := filepath.Join(fs.homeDir, "Library", "Preferences", "com.apple.LaunchServices")
+	if err := os.MkdirAll(prefsDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	handlers := encodeBinaryPlist(t, map[string]any{
+		"LSHandlers": []any{
+			map[string]any{"LSHandlerContentType": "public.html", "LSHandlerRoleAll": "com.other.editor"},
+			map[string]any{"LSHandlerURLScheme": "https", "LSHandlerRoleAll": "com.test.browser"},
+		},
+	})
+	if err := os.WriteFile(filepath.Join(prefsDir, "com.apple.launchservices.secure.plist"), handlers, 0644); err != nil {
+		t.Fatalf("failed to write LaunchServices prefs: %v", err)
+	}
+
+	browser := AppInfo{Name: "Browser", BundleID: "com.test.browser", Path: fs.createApp(t, "Browser", "com.test.browser")}
+	other := AppInfo{Name: "Other", BundleID: "com.test.other", Path: fs.createApp(t, "Other", "com.test.other")}
+
+	tests := []struct {
+		app       AppInfo
+		protected bool
+	}{
+		{AppInfo{Name: "Safari", BundleID: "com.apple.Safari", Path: "/Applications/Safari.app"}, true},
+		{AppInfo{Name: "Gone", BundleID: "com.apple.gone"}, true},
+		{AppInfo{Name: "Utility", Path: "/System/Applications/Utilities/Utility.app"}, true},
+		{AppInfo{Name: "Local", Path: "/usr/local/Local.app"}, false},
+		{browser, true},
+		{other, false},
+	}
+	for _, tt := range tests {
+		if got := protectionReason(tt.app) != ""; got != tt.protected {
+			t.Errorf("%s: expected protected=%v, got reason %q", tt.app.Name, tt.protected, protectionReason(tt.app))
+		}
+	}
+
+	os.Setenv("__CFBundleIdentifier", "com.test.other")
+	defer os.Unsetenv("__CFBundleIdentifier")
+	if protectionReason(other) == "" {
+		t.Error("expected the terminal zaap runs in to be protected")
+	}
+}
+
+func TestOnSealedVolumeFollowsAppSymlink(t *testing.T) {
+	fs := newTestFS(t)
+	volumeRoot = fs.rootDir
+	defer func() { volumeRoot = "" }()
+
+	appsDir := filepath.Join(fs.rootDir, "Applications")
+	if err := os.MkdirAll(appsDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	link := filepath.Join(appsDir, "Safari.app")
+	if err := os.Symlink("/System/Cryptexes/App/System/Applications/Safari.app", link); err != nil {
+		t.Fatalf("failed to symlink: %v", err)
+	}
+
+	if !onSealedVolume(link) {
+		t.Error("expected a symlink into /System to be on the sealed volume")
+	}
+	if onSealedVolume(filepath.Join(appsDir, "Other.app")) {
+		t.Error("expected a regular app not to be on the sealed volume")
+	}
+	if !onSealedVolume(filepath.Join(fs.rootDir, "Library", "Apple", "Thing")) {
+		t.Error("expected /Library/Apple on the target volume to be sealed")
+	}
+}
+
+func TestProtectedFindingsAreKept(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	outputFormat = "json"
+	defer func() { outputFormat = "text" }()
+
+	applePref := fs.createPrefFile(t, "com.apple.Photos", ".plist")
+	ownPref := fs.createPrefFile(t, "com.test.photos", ".plist")
+	app := AppInfo{
+		Name: "Photos",
+		Findings: []Finding{
+			newFinding(applePref, "associated-files", matchName),
+			newFinding(ownPref, "associated-files", matchBundleID),
+		},
+	}
+	markProtected(&app)
+
+	if !app.Findings[0].kept() || app.Findings[1].kept() {
+		t.Fatalf("expected only the Apple preference to be kept: %+v", app.Findings)
+	}
+	if result := removeFinding(nil, app.Name, "associated-files", app.Findings[0]); result.Action != "kept" || result.Reason == "" {
+		t.Errorf("expected the Apple preference to be kept with a reason, got %+v", result)
+	}
+	if exists, _ := pathExists(applePref); !exists {
+		t.Error("protected item should not be removed")
+	}
+
+	forceProtected = true
+	defer func() { forceProtected = false }()
+	if app.Findings[0].kept() {
+		t.Error("expected --force-protected to allow removing the Apple preference")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func runRestore(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		listSessions()
+		return
+	}
+
+	journal, err := loadJournalSession(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if restoreShow {
+		printSession(journal)
+		return
+	}
+
+	indexes, err := selectSessionItems(journal, args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, i := range indexes {
+		e := journal.Items[i]
+		if dryRun {
+			fmt.Printf("Would restore: %s\n", e.Original)
+			continue
+		}
+		err := journal.restore(i)
+		switch {
+		case err == nil:
+			fmt.Printf("Restored: %s\n", e.Original)
+		case errors.Is(err, errRestoreConflict):
+			fmt.Fprintf(os.Stderr, "Conflict: %s already exists; left in Trash at %s\n", e.Original, e.Trashed)
+			failed++
+		default:
+			fmt.Fprintf(os.Stderr, "Error restoring %s: %v\n", e.Original, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d item(s) could not be restored.\n", failed)
+		os.Exit(1)
+	}
+}
+
+func listSessions() {
+	sessions, err := listJournalSessions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No uninstall sessions recorded.")
+		return
+	}
+
+	fmt.Println("Uninstall sessions:")
+	fmt.Println("-------------------")
+	for _, j := range sessions {
+		restorable := 0
+		for _, e := range j.Items {
+			if e.Trashed != "" && !e.Restored {
+				restorable++
+			}
+		}
+		fmt.Printf("%s  %s  %d item(s), %d restorable  %v\n",
+			j.ID, j.Started.Format("2006-01-02 15:04"), len(j.Items), restorable, j.apps())
+	}
+}
+
+func printSession(journal *journalSession) {
+	fmt.Printf("Session %s (%s):\n", journal.ID, journal.Started.Format("2006-01-02 15:04"))
+	for i, e := range journal.Items {
+		status := "in Trash"
+		switch {
+		case e.Restored:
+			status = "restored"
+		case e.Trashed == "":
+			status = "deleted permanently"
+		}
+		fmt.Printf("%d. [%s] %s (%s)\n", i+1, e.App, e.Original, status)
+	}
+}
+
+// selectSessionItems resolves item numbers or original paths to indexes into
+// the session; no selectors means every item not yet restored.
+func selectSessionItems(journal *journalSession, selectors []string) ([]int, error) {
+	var indexes []int
+	if len(selectors) == 0 {
+		for i, e := range journal.Items {
+			if !e.Restored {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes, nil
+	}
+
+	for _, sel := range selectors {
+		if n, err := strconv.Atoi(sel); err == nil {
+			if n < 1 || n > len(journal.Items) {
+				return nil, fmt.Errorf("no item %d in session %s", n, journal.ID)
+			}
+			indexes = append(indexes, n-1)
+			continue
+		}
+		found := false
+		for i, e := range journal.Items {
+			if e.Original == sel {
+				indexes = append(indexes, i)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no item %s in session %s", sel, journal.ID)
+		}
+	}
+	return indexes, nil
+}
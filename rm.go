@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func runRm(cmd *cobra.Command, args []string) {
+	if len(args) == 0 && len(rmBundleIDs) == 0 && fromFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: give an application name, a path to an .app, --bundle-id or --from-file")
+		os.Exit(1)
+	}
+	deleteApps(resolveTargets(args, rmBundleIDs))
+}
+
+// resolveTargets turns names, paths and bundle IDs, plus any listed in
+// --from-file, into the apps to remove. It also returns every installed
+// app and exits if any target cannot be resolved.
+func resolveTargets(args, bundleIDs []string) ([]AppInfo, []AppInfo) {
+	if fromFile != "" {
+		listed, err := readTargetList(fromFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		args = append(args, listed...)
+	}
+
+	installed, err := getInstalledApps()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var targets []AppInfo
+	add := func(app AppInfo, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, t := range targets {
+			if (app.Path != "" && t.Path == app.Path) || (app.Path == "" && strings.EqualFold(t.BundleID, app.BundleID)) {
+				return
+			}
+		}
+		// An app given by path may live anywhere, so zaap may delete that
+		// bundle, though nothing else next to it.
+		if app.Path != "" {
+			if abs, err := filepath.Abs(app.Path); err == nil {
+				trustPath(abs)
+			}
+		}
+		targets = append(targets, app)
+	}
+	for _, arg := range args {
+		add(lookupApp(arg, installed))
+	}
+	for _, id := range bundleIDs {
+		add(lookupBundleID(id, installed))
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no applications given")
+		os.Exit(1)
+	}
+	return targets, installed
+}
+
+// readTargetList reads one name, path or bundle ID per line from path, or
+// from stdin for "-". Blank lines and lines starting with # are skipped.
+func readTargetList(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var names []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, scanner.Err()
+}
+
+// lookupApp finds the app meant by arg: a path to an .app bundle anywhere on
+// disk, the name of an installed app, or failing that a bundle ID.
+func lookupApp(arg string, installed []AppInfo) (AppInfo, error) {
+	if isAppPath(arg) {
+		return appFromPath(arg)
+	}
+
+	var matches []AppInfo
+	for _, app := range installed {
+		if strings.EqualFold(app.Name, arg) {
+			matches = append(matches, app)
+		}
+	}
+	if len(matches) == 0 {
+		if isReverseDNS(strings.Split(strings.ToLower(arg), ".")) {
+			return lookupBundleID(arg, installed)
+		}
+		return AppInfo{}, fmt.Errorf("application not found: %s", arg)
+	}
+	return pickApp(arg, matches)
+}
+
+// lookupBundleID returns the installed app with bundleID, or an app without
+// a path when none is installed so its leftovers can still be cleaned up.
+func lookupBundleID(bundleID string, installed []AppInfo) (AppInfo, error) {
+	var matches []AppInfo
+	for _, app := range installed {
+		if strings.EqualFold(app.BundleID, bundleID) {
+			matches = append(matches, app)
+		}
+	}
+	if len(matches) == 0 {
+		return AppInfo{Name: bundleID, BundleID: bundleID}, nil
+	}
+	return pickApp(bundleID, matches)
+}
+
+// pickApp returns the only app in matches, or an error listing them when
+// the name is ambiguous.
+func pickApp(name string, matches []AppInfo) (AppInfo, error) {
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "multiple applications match %s:\n", name)
+	for _, app := range matches {
+		fmt.Fprintf(&b, "  - %s (%s)\n", app.Path, appLabel(app))
+	}
+	b.WriteString("Use --apps-dir or a path to choose one.")
+	return AppInfo{}, fmt.Errorf("%s", b.String())
+}
+
+// isAppPath reports whether arg names a bundle on disk rather than an
+// installed app. A bare "Foo.app" only counts if it exists, since names
+// such as com.test.app are not paths.
+func isAppPath(arg string) bool {
+	if strings.ContainsRune(arg, filepath.Separator) {
+		return true
+	}
+	if !strings.HasSuffix(strings.ToLower(arg), ".app") {
+		return false
+	}
+	exists, _ := pathExists(arg)
+	return exists
+}
+
+func appFromPath(path string) (AppInfo, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return AppInfo{}, err
+	}
+	if !strings.HasSuffix(strings.ToLower(path), ".app") {
+		return AppInfo{}, fmt.Errorf("%s is not an application bundle", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return AppInfo{}, err
+	}
+	if !info.IsDir() {
+		return AppInfo{}, fmt.Errorf("%s is not an application bundle", path)
+	}
+
+	app := AppInfo{
+		Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Path: path,
+		Root: filepath.Dir(path),
+	}
+	if err := loadBundleInfo(&app); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", app.Path, err)
+	}
+	return app, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsAppPath(t *testing.T) {
+	tests := map[string]bool{
+		"Safari":                        false,
+		"Google Chrome":                 false,
+		"Foo.app":                       false,
+		"/Volumes/Foo/Foo.app":          true,
+		"~/Downloads/Foo.APP":           true,
+		"./Foo":                         true,
+		"com.test.app":                  false,
+		"/Applications/Utilities/X.app": true,
+	}
+	for arg, want := range tests {
+		if got := isAppPath(arg); got != want {
+			t.Errorf("isAppPath(%q) = %v, want %v", arg, got, want)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "Foo.app"), 0755); err != nil {
+		t.Fatalf("failed to create bundle: %v", err)
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	if !isAppPath("Foo.app") {
+		t.Error("expected an existing Foo.app in the current directory to be a path")
+	}
+}
+
+func TestAppFromPath(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	appPath := fs.createApp(t, "TestApp", "com.test.app")
+	moved := filepath.Join(fs.rootDir, "Downloads", "TestApp.app")
+	if err := os.MkdirAll(filepath.Dir(moved), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.Rename(appPath, moved); err != nil {
+		t.Fatalf("failed to move app: %v", err)
+	}
+
+	app, err := appFromPath(moved)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if app.Name != "TestApp" || app.Path != moved || app.BundleID != "com.test.app" {
+		t.Errorf("unexpected app: %+v", app)
+	}
+
+	if _, err := appFromPath(filepath.Join(fs.rootDir, "Downloads", "Missing.app")); err == nil {
+		t.Error("expected an error for a missing bundle")
+	}
+	if _, err := appFromPath(fs.homeDir); err == nil {
+		t.Error("expected an error for a folder that is not an .app")
+	}
+}
+
+func TestDeleteByBundleIDWithoutApp(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	outputFormat, assumeYes = "json", true
+	defer func() { outputFormat, assumeYes = "text", false }()
+
+	fs.createApp(t, "Other", "com.other.app")
+	prefPath := fs.createPrefFile(t, "com.gone.app", ".plist")
+	cachePath := fs.createCachesDir(t, "com.gone.app")
+	otherPref := fs.createPrefFile(t, "com.other.app", ".plist")
+
+	installed, err := getInstalledApps()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	target, err := lookupApp("com.gone.app", installed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target.Path != "" || target.BundleID != "com.gone.app" {
+		t.Fatalf("expected an uninstalled target, got %+v", target)
+	}
+	if len(installed) != 1 {
+		t.Fatalf("expected installed apps to be returned, got %v", installed)
+	}
+
+	deleteApps([]AppInfo{target}, installed)
+
+	for _, path := range []string{prefPath, cachePath} {
+		if exists, _ := pathExists(path); exists {
+			t.Errorf("expected %s to be removed", path)
+		}
+	}
+	if exists, _ := pathExists(otherPref); !exists {
+		t.Error("another app's preferences should be left alone")
+	}
+
+	found, err := lookupBundleID("COM.OTHER.APP", installed)
+	if err != nil || found.Name != "Other" || found.Path == "" {
+		t.Errorf("expected the installed app to be found by bundle ID, got %+v", found)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesVersion is the newest rules format this build understands.
+const rulesVersion = 1
+
+//go:embed rules.yaml
+var embeddedRules []byte
+
+// A rulesFile maps bundle IDs to paths and globs of leftovers that the
+// matcher cannot find by name, such as dotfiles in the home folder.
+type rulesFile struct {
+	Version int                 `yaml:"version"`
+	Apps    map[string][]string `yaml:"apps"`
+}
+
+// knownRules holds the embedded and local rules by lower-case bundle ID.
+var knownRules = map[string][]string{}
+
+// rulesDirs lists the rules.d folders next to each config file.
+func rulesDirs() []string {
+	var dirs []string
+	for _, path := range configPaths() {
+		dirs = append(dirs, filepath.Join(filepath.Dir(path), "rules.d"))
+	}
+	return dirs
+}
+
+// loadRules reads the embedded rules and every *.yaml file in dirs.
+func loadRules(dirs ...string) error {
+	rules := map[string][]string{}
+	if err := parseRules(embeddedRules, rules); err != nil {
+		return fmt.Errorf("embedded rules: %w", err)
+	}
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+		if err != nil {
+			return err
+		}
+		for _, path := range files {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := parseRules(data, rules); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	knownRules = rules
+	return nil
+}
+
+func parseRules(data []byte, rules map[string][]string) error {
+	var f rulesFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return err
+	}
+	switch {
+	case f.Version == 0:
+		return fmt.Errorf("missing version")
+	case f.Version > rulesVersion:
+		return fmt.Errorf("version %d needs a newer zaap", f.Version)
+	}
+
+	for id, patterns := range f.Apps {
+		id = strings.ToLower(id)
+		for _, pattern := range patterns {
+			if err := checkRulePattern(pattern); err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			if !slices.Contains(rules[id], pattern) {
+				rules[id] = append(rules[id], pattern)
+			}
+		}
+	}
+	return nil
+}
+
+func checkRulePattern(pattern string) error {
+	if !strings.HasPrefix(pattern, "~/") && !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("path %q must be absolute or start with ~/", pattern)
+	}
+	if slices.Contains(strings.Split(pattern, "/"), "..") {
+		return fmt.Errorf("path %q must not contain ..", pattern)
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("path %q: %w", pattern, err)
+	}
+	if !isHomeEntryRule(pattern) && !inScannerLocation(pattern) {
+		return fmt.Errorf("path %q must be a file directly in ~/ or inside a folder zaap scans", pattern)
+	}
+	return nil
+}
+
+// isHomeEntryRule reports whether pattern names one entry directly in the
+// home folder, such as ~/.zoomus, without any glob.
+func isHomeEntryRule(pattern string) bool {
+	name, ok := strings.CutPrefix(pattern, "~/")
+	return ok && name != "" && !strings.Contains(name, "/") && !hasMeta(name)
+}
+
+// inScannerLocation reports whether everything pattern matches lies
+// strictly inside a scanner location: its fixed part up to the first glob
+// must be a location or below one.
+func inScannerLocation(pattern string) bool {
+	path := rulePath(pattern)
+	fixed, strict := path, true
+	if i := strings.IndexAny(path, "*?[\\"); i >= 0 {
+		fixed, strict = filepath.Dir(path[:i]+"x"), false
+	}
+	for _, s := range registeredScanners() {
+		for _, dir := range s.Locations() {
+			if isUnder(fixed, dir) && (!strict || fixed != dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[\\")
+}
+
+// rulePath resolves a rule pattern on the target system.
+func rulePath(pattern string) string {
+	if strings.HasPrefix(pattern, "~/") {
+		return homePath(strings.TrimPrefix(pattern, "~/"))
+	}
+	return systemPath(pattern)
+}
+
+// knownFindings returns the paths in idx the rules list for any of m's
+// bundle IDs. Each lands in the category of the scanner searching its
+// folder, or in associated files when no scanner does.
+func knownFindings(idx *libraryIndex, m *matcher) []Finding {
+	var found []Finding
+	for _, id := range m.bundleIDs {
+		for _, pattern := range knownRules[id] {
+			for _, path := range idx.glob(rulePath(pattern)) {
+				found = append(found, newFinding(path, knownCategory(path), matchKnown))
+			}
+		}
+	}
+	return found
+}
+
+func knownCategory(path string) string {
+	for _, s := range registeredScanners() {
+		for _, dir := range s.Locations() {
+			if isUnder(path, dir) {
+				return s.ID()
+			}
+		}
+	}
+	return "associated-files"
+}
+
+// isKnownLeftover reports whether path is an entry of the home folder that
+// the rules list by name, which lets checkRemovable accept such dotfiles
+// although they are outside every scanned folder.
+func isKnownLeftover(path string) bool {
+	for _, patterns := range knownRules {
+		for _, pattern := range patterns {
+			if isHomeEntryRule(pattern) && rulePath(pattern) == path {
+				return true
+			}
+		}
+	}
+	return false
+}
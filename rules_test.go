@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRules(t *testing.T) {
+	defer func() { knownRules = map[string][]string{} }()
+	dir := t.TempDir()
+	writeConfig(t, filepath.Join(dir, "corp.yaml"), `
+version: 1
+apps:
+  COM.Corp.Tool:
+    - ~/.corptool
+  us.zoom.xos:
+    - ~/.zoomus
+    - ~/Library/Logs/zoom.us
+`)
+
+	if err := loadRules(dir, filepath.Join(dir, "missing")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(knownRules["com.getdropbox.dropbox"]) == 0 {
+		t.Error("expected the embedded rules to be loaded")
+	}
+	if got := knownRules["com.corp.tool"]; len(got) != 1 || got[0] != "~/.corptool" {
+		t.Errorf("expected local rules keyed by lower-case bundle ID, got %v", got)
+	}
+	zoom := knownRules["us.zoom.xos"]
+	count := 0
+	for _, p := range zoom {
+		if p == "~/.zoomus" {
+			count++
+		}
+	}
+	if count != 1 || zoom[len(zoom)-1] != "~/Library/Logs/zoom.us" {
+		t.Errorf("expected local rules to extend embedded ones without duplicates, got %v", zoom)
+	}
+
+	bad := map[string]string{
+		"no version":    "apps: {}\n",
+		"newer version": "version: 99\napps: {}\n",
+		"relative path": "version: 1\napps:\n  com.x.y: [Library/x]\n",
+		"parent path":   "version: 1\napps:\n  com.x.y: [~/../x]\n",
+		"unknown key":   "version: 1\nrules: {}\n",
+		"home glob":     "version: 1\napps:\n  com.x.y: [~/*]\n",
+		"home subdir":   "version: 1\napps:\n  com.x.y: [~/Documents/x]\n",
+		"library glob":  "version: 1\napps:\n  com.x.y: [~/Library/*]\n",
+		"apps glob":     "version: 1\napps:\n  com.x.y: [/Applications/*]\n",
+		"a location":    "version: 1\napps:\n  com.x.y: [~/Library/Caches]\n",
+	}
+	for name, content := range bad {
+		if err := parseRules([]byte(content), map[string][]string{}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestKnownFindings(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+	defer func() { knownRules = map[string][]string{} }()
+	knownRules = map[string][]string{
+		"com.test.app": {
+			"~/.testapp",
+			"~/Library/LaunchAgents/com.vendor.updater.*.plist",
+			"~/.missing",
+		},
+	}
+
+	dotfile := filepath.Join(fs.homeDir, ".testapp")
+	if err := os.MkdirAll(dotfile, 0755); err != nil {
+		t.Fatalf("failed to create dotfile dir: %v", err)
+	}
+	agent := fs.createLaunchAgent(t, "com.vendor.updater.wake")
+
+	app := AppInfo{Name: "Test App", BundleID: "com.test.app", Path: fs.createApp(t, "Test App", "com.test.app")}
+	if err := scanAssociatedFiles(context.Background(), &app); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{dotfile: "associated-files", agent: "startup-items"}
+	for _, f := range app.Findings {
+		if category, ok := want[f.Path]; ok {
+			if f.Category != category || f.Rule != matchKnown || f.Confidence != confidenceHigh {
+				t.Errorf("unexpected finding %+v", f)
+			}
+			delete(want, f.Path)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("missing known findings: %v", want)
+	}
+
+	if err := checkRemovable(dotfile); err != nil {
+		t.Errorf("expected a known dotfile to be removable, got %v", err)
+	}
+	if err := checkRemovable(filepath.Join(fs.homeDir, ".ssh")); err == nil {
+		t.Error("expected other dotfiles to be refused")
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type process struct {
+	pid     int
+	command string
+}
+
+// A processController lists, quits and kills processes. Tests replace it
+// so nothing real is touched.
+type processController interface {
+	list() ([]process, error)
+	// quit asks the app with bundleID to quit the way the Dock does.
+	quit(bundleID string) error
+	kill(pid int) error
+}
+
+type systemProcesses struct{}
+
+func (systemProcesses) list() ([]process, error) {
+	out, err := exec.Command("ps", "-axww", "-o", "pid=,comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing processes: %w", err)
+	}
+	return parsePS(string(out)), nil
+}
+
+func (systemProcesses) quit(bundleID string) error {
+	script := fmt.Sprintf("tell application id %q to quit", bundleID)
+	if out, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func (systemProcesses) kill(pid int) error {
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
+
+var processes processController = systemProcesses{}
+
+// quitTimeout is how long a quit or killed app gets to go away.
+var quitTimeout = 10 * time.Second
+
+// parsePS reads the output of ps -o pid=,comm=, whose commands are full
+// executable paths that may contain spaces.
+func parsePS(out string) []process {
+	var procs []process
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		command := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+		procs = append(procs, process{pid: pid, command: command})
+	}
+	return procs
+}
+
+// appProcesses returns the processes running an executable inside app's
+// bundle, which includes its helper apps, XPC services and login items.
+func appProcesses(procs []process, app AppInfo) []process {
+	var running []process
+	for _, p := range procs {
+		if isUnder(p.command, app.Path) {
+			running = append(running, p)
+		}
+	}
+	return running
+}
+
+// checkRunningAction validates --running.
+func checkRunningAction() error {
+	switch ifRunning {
+	case "", "abort", "quit", "kill":
+		return nil
+	}
+	return fmt.Errorf("unknown --running action %q (want abort, quit or kill)", ifRunning)
+}
+
+// A runningApp is a target that is still running, with what to do about it.
+type runningApp struct {
+	app    AppInfo
+	procs  []process
+	action string
+}
+
+// findRunning decides what to do about every target that is running.
+// --running decides; without it zaap asks when reader is set and refuses
+// otherwise. It stops nothing, so when one app may not be stopped the
+// others are left running too.
+func findRunning(targets []AppInfo, reader *bufio.Reader) ([]runningApp, error) {
+	if volumeRoot != "" {
+		return nil, nil
+	}
+	procs, err := processes.list()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not check for running applications: %v\n", err)
+		return nil, nil
+	}
+
+	var found []runningApp
+	for _, app := range targets {
+		if app.Path == "" {
+			continue
+		}
+		running := appProcesses(procs, app)
+		if len(running) == 0 {
+			continue
+		}
+		if dryRun {
+			fmt.Fprintf(os.Stderr, "Note: %s is running (%s).\n", app.Name, pidList(running))
+			continue
+		}
+
+		action := ifRunning
+		if action == "" && reader != nil {
+			fmt.Printf("\n%s is running (%s). Quit it, kill it or abort? (q/k/a): ", app.Name, pidList(running))
+			line, _ := reader.ReadString('\n')
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "q":
+				action = "quit"
+			case "k":
+				action = "kill"
+			}
+		}
+		switch {
+		case action == "quit" && app.BundleID == "":
+			return nil, fmt.Errorf("%s has no bundle ID to ask it to quit; use --running kill", app.Name)
+		case action != "quit" && action != "kill":
+			return nil, fmt.Errorf("%s is running (%s); quit it first or use --running quit or --running kill", app.Name, pidList(running))
+		}
+		found = append(found, runningApp{app, running, action})
+	}
+	return found, nil
+}
+
+// stopRunning quits or kills the apps findRunning found.
+func stopRunning(running []runningApp) error {
+	for _, r := range running {
+		if err := stopApp(r.app, r.procs, r.action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopApp quits or kills app's running processes and waits for them to
+// exit. Any other action refuses to go on.
+func stopApp(app AppInfo, running []process, action string) error {
+	switch action {
+	case "quit":
+		if err := processes.quit(app.BundleID); err != nil {
+			return fmt.Errorf("could not quit %s: %w", app.Name, err)
+		}
+	case "kill":
+		for _, p := range running {
+			if err := processes.kill(p.pid); err != nil {
+				return fmt.Errorf("could not kill %s (pid %d): %w", app.Name, p.pid, err)
+			}
+		}
+	default:
+		return fmt.Errorf("%s is running (%s); quit it first or use --running quit or --running kill", app.Name, pidList(running))
+	}
+
+	deadline := time.Now().Add(quitTimeout)
+	for {
+		procs, err := processes.list()
+		if err != nil {
+			return err
+		}
+		left := appProcesses(procs, app)
+		if len(left) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s is still running (%s)", app.Name, pidList(left))
+		}
+		time.Sleep(quitTimeout / 50)
+	}
+}
+
+func pidList(procs []process) string {
+	var pids []string
+	for _, p := range procs {
+		pids = append(pids, strconv.Itoa(p.pid))
+	}
+	if len(pids) == 1 {
+		return "pid " + pids[0]
+	}
+	return "pids " + strings.Join(pids, ", ")
+}
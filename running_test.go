@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProcesses is a process table whose quit and kill remove processes,
+// unless the app is listed as stubborn.
+type fakeProcesses struct {
+	procs    []process
+	apps     map[string]string
+	stubborn map[string]bool
+	calls    []string
+}
+
+func (f *fakeProcesses) list() ([]process, error) {
+	return slices.Clone(f.procs), nil
+}
+
+func (f *fakeProcesses) quit(bundleID string) error {
+	f.calls = append(f.calls, "quit "+bundleID)
+	if f.stubborn[bundleID] {
+		return nil
+	}
+	f.procs = slices.DeleteFunc(f.procs, func(p process) bool { return isUnder(p.command, f.apps[bundleID]) })
+	return nil
+}
+
+func (f *fakeProcesses) kill(pid int) error {
+	f.calls = append(f.calls, "kill")
+	f.procs = slices.DeleteFunc(f.procs, func(p process) bool { return p.pid == pid })
+	return nil
+}
+
+func TestParsePS(t *testing.T) {
+	out := `    1 /sbin/launchd
+  412 /Applications/Test App.app/Contents/MacOS/Test App
+  413 /Applications/Test App.app/Contents/Frameworks/Test App Helper (GPU).app/Contents/MacOS/Test App Helper (GPU)
+bogus line
+`
+	want := []process{
+		{1, "/sbin/launchd"},
+		{412, "/Applications/Test App.app/Contents/MacOS/Test App"},
+		{413, "/Applications/Test App.app/Contents/Frameworks/Test App Helper (GPU).app/Contents/MacOS/Test App Helper (GPU)"},
+	}
+	if got := parsePS(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestStopApp(t *testing.T) {
+	saved, savedTimeout := processes, quitTimeout
+	defer func() { processes, quitTimeout = saved, savedTimeout }()
+	quitTimeout = 50 * time.Millisecond
+
+	app := AppInfo{Name: "Test App", BundleID: "com.test.app", Path: "/Applications/Test App.app"}
+	table := func() *fakeProcesses {
+		return &fakeProcesses{
+			procs: []process{
+				{1, "/sbin/launchd"},
+				{412, "/Applications/Test App.app/Contents/MacOS/Test App"},
+				{413, "/Applications/Test App.app/Contents/Frameworks/Helper.app/Contents/MacOS/Helper"},
+				{500, "/Applications/Test App Pro.app/Contents/MacOS/Test App Pro"},
+			},
+			apps:     map[string]string{"com.test.app": app.Path},
+			stubborn: map[string]bool{},
+		}
+	}
+
+	fake := table()
+	processes = fake
+	running := appProcesses(fake.procs, app)
+	if len(running) != 2 {
+		t.Fatalf("expected the app and its helper to be running, got %+v", running)
+	}
+
+	if err := stopApp(app, running, "abort"); err == nil || !strings.Contains(err.Error(), "pids 412, 413") {
+		t.Errorf("expected abort to refuse, got %v", err)
+	}
+	if len(fake.calls) > 0 {
+		t.Errorf("expected abort not to touch processes, got %v", fake.calls)
+	}
+
+	if err := stopApp(app, running, "quit"); err != nil {
+		t.Errorf("unexpected error quitting: %v", err)
+	}
+	if len(fake.procs) != 2 {
+		t.Errorf("expected only unrelated processes to be left, got %+v", fake.procs)
+	}
+
+	fake = table()
+	fake.stubborn["com.test.app"] = true
+	processes = fake
+	if err := stopApp(app, running, "quit"); err == nil || !strings.Contains(err.Error(), "still running") {
+		t.Errorf("expected an app that does not quit to be reported, got %v", err)
+	}
+
+	fake = table()
+	processes = fake
+	if err := stopApp(app, running, "kill"); err != nil {
+		t.Errorf("unexpected error killing: %v", err)
+	}
+	if len(appProcesses(fake.procs, app)) != 0 || len(fake.calls) != 2 {
+		t.Errorf("expected both processes to be killed, got %v", fake.calls)
+	}
+}
+
+func TestFindRunning(t *testing.T) {
+	saved, savedTimeout := processes, quitTimeout
+	defer func() { processes, quitTimeout, ifRunning = saved, savedTimeout, "" }()
+	quitTimeout = 50 * time.Millisecond
+
+	one := AppInfo{Name: "One", BundleID: "com.test.one", Path: "/Applications/One.app"}
+	two := AppInfo{Name: "Two", BundleID: "com.test.two", Path: "/Applications/Two.app"}
+	idle := AppInfo{Name: "Idle", BundleID: "com.test.idle", Path: "/Applications/Idle.app"}
+	table := func() *fakeProcesses {
+		f := &fakeProcesses{
+			procs: []process{
+				{10, "/Applications/One.app/Contents/MacOS/One"},
+				{20, "/Applications/Two.app/Contents/MacOS/Two"},
+			},
+			apps: map[string]string{"com.test.one": one.Path, "com.test.two": two.Path},
+		}
+		processes = f
+		return f
+	}
+	targets := []AppInfo{one, idle, two}
+
+	fake := table()
+	ifRunning = ""
+	if _, err := findRunning(targets, nil); err == nil || !strings.Contains(err.Error(), "One is running") {
+		t.Errorf("expected a running app to be refused without a prompt, got %v", err)
+	}
+
+	// Agreeing to quit the first app but aborting on the second stops neither.
+	_, err := findRunning(targets, bufio.NewReader(strings.NewReader("q\na\n")))
+	if err == nil || !strings.Contains(err.Error(), "Two is running") {
+		t.Errorf("expected the abort on Two to be reported, got %v", err)
+	}
+	if len(fake.calls) > 0 {
+		t.Errorf("expected nothing to be stopped before every app was checked, got %v", fake.calls)
+	}
+
+	running, err := findRunning(targets, bufio.NewReader(strings.NewReader("q\nk\n")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(running) != 2 || running[0].action != "quit" || running[1].action != "kill" {
+		t.Fatalf("unexpected plan %+v", running)
+	}
+	if err := stopRunning(running); err != nil {
+		t.Errorf("unexpected error stopping: %v", err)
+	}
+	if !reflect.DeepEqual(fake.calls, []string{"quit com.test.one", "kill"}) || len(fake.procs) != 0 {
+		t.Errorf("expected One to be quit and Two killed, got %v, left %+v", fake.calls, fake.procs)
+	}
+
+	table()
+	ifRunning = "kill"
+	if running, err := findRunning(targets, nil); err != nil || len(running) != 2 {
+		t.Errorf("expected --running kill to apply to every app, got %+v, %v", running, err)
+	}
+
+	table()
+	ifRunning = "quit"
+	noID := AppInfo{Name: "One", Path: one.Path}
+	if _, err := findRunning([]AppInfo{noID}, nil); err == nil {
+		t.Error("expected quitting an app without a bundle ID to be refused")
+	}
+
+	table()
+	ifRunning = ""
+	dryRun = true
+	defer func() { dryRun = false }()
+	if running, err := findRunning(targets, nil); err != nil || len(running) != 0 {
+		t.Errorf("expected a dry run only to note running apps, got %+v, %v", running, err)
+	}
+}
+
+func TestCheckRunningAction(t *testing.T) {
+	defer func() { ifRunning = "" }()
+	for _, action := range []string{"", "abort", "quit", "kill"} {
+		ifRunning = action
+		if err := checkRunningAction(); err != nil {
+			t.Errorf("%q: unexpected error: %v", action, err)
+		}
+	}
+	ifRunning = "stop"
+	if err := checkRunningAction(); err == nil {
+		t.Error("expected an unknown action to be refused")
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import "path/filepath"
+
+// A Scanner finds one category of items belonging to an app. Scanners are
+// registered once and everything that lists, prints or deletes findings
+// iterates over the registry rather than knowing about categories.
+type Scanner interface {
+	// ID is the stable machine-readable category name used in JSON output.
+	ID() string
+	// Name is the human-readable category title.
+	Name() string
+	// Locations returns the directories searched, resolved for the target
+	// system.
+	Locations() []string
+	// Risk describes how much damage a wrong match in this category does.
+	Risk() riskLevel
+	// Scan looks up app's items in idx rather than reading the disk.
+	Scan(idx *libraryIndex, app *AppInfo, m *matcher) []Finding
+}
+
+type riskLevel int
+
+const (
+	riskLow riskLevel = iota
+	riskMedium
+	riskHigh
+)
+
+func (r riskLevel) String() string {
+	switch r {
+	case riskLow:
+		return "low"
+	case riskMedium:
+		return "medium"
+	}
+	return "high"
+}
+
+type matchRule int
+
+const (
+	// matchKnown marks a path listed for the app in the rules database. The
+	// matcher never produces it, so it is not part of matchRules.
+	matchKnown matchRule = iota
+	// matchAppPath marks a launchd job whose program lies inside the app
+	// bundle. Like matchKnown it is not part of matchRules.
+	matchAppPath
+	// matchBundleID matches an entry named after a bundle ID, with or
+	// without an extension such as .plist or .savedState.
+	matchBundleID
+	// matchAppGroup matches an entry named after one of the app groups in
+	// the app's entitlements.
+	matchAppGroup
+	// matchBundlePrefix matches entries extending a bundle ID, such as
+	// com.foo.bar.helper for com.foo.bar.
+	matchBundlePrefix
+	// matchTeamID matches entries starting with the Team ID that signed the
+	// app, as group containers of that developer's apps do.
+	matchTeamID
+	// matchName matches entries starting with the app name on a word
+	// boundary.
+	matchName
+	// matchVendor matches entries from the vendor in the bundle ID.
+	matchVendor
+)
+
+// matchRules lists every rule from most to least specific.
+var matchRules = []matchRule{matchBundleID, matchAppGroup, matchBundlePrefix, matchTeamID, matchName, matchVendor}
+
+func (r matchRule) String() string {
+	switch r {
+	case matchKnown:
+		return "known"
+	case matchAppPath:
+		return "app-path"
+	case matchBundleID:
+		return "bundle-id"
+	case matchAppGroup:
+		return "app-group"
+	case matchBundlePrefix:
+		return "bundle-id-prefix"
+	case matchTeamID:
+		return "team-id"
+	case matchName:
+		return "name"
+	}
+	return "vendor"
+}
+
+func (r matchRule) description() string {
+	switch r {
+	case matchKnown:
+		return "known leftover"
+	case matchAppPath:
+		return "runs from the app"
+	case matchBundleID:
+		return "bundle ID"
+	case matchAppGroup:
+		return "app group"
+	case matchBundlePrefix:
+		return "bundle ID prefix"
+	case matchTeamID:
+		return "Team ID"
+	case matchName:
+		return "name match"
+	}
+	return "vendor match"
+}
+
+func (r matchRule) confidence() confidence {
+	switch r {
+	case matchKnown, matchAppPath, matchBundleID, matchAppGroup:
+		return confidenceHigh
+	case matchBundlePrefix, matchTeamID, matchName:
+		return confidenceMedium
+	}
+	return confidenceLow
+}
+
+// A location is a directory searched by a scanner together with the rules
+// an entry in it has to satisfy.
+type location struct {
+	dir   string
+	home  bool
+	rules []matchRule
+}
+
+func homeLocation(dir string, rules ...matchRule) location {
+	return location{dir: dir, home: true, rules: rules}
+}
+
+func systemLocation(dir string, rules ...matchRule) location {
+	return location{dir: dir, rules: rules}
+}
+
+func (l location) path() string {
+	if l.home {
+		return homePath(l.dir)
+	}
+	return systemPath(l.dir)
+}
+
+// dirScanner matches the entries of a fixed set of directories.
+type dirScanner struct {
+	id        string
+	name      string
+	risk      riskLevel
+	locations []location
+}
+
+func (s *dirScanner) ID() string      { return s.id }
+func (s *dirScanner) Name() string    { return s.name }
+func (s *dirScanner) Risk() riskLevel { return s.risk }
+
+func (s *dirScanner) Locations() []string {
+	var dirs []string
+	for _, loc := range s.locations {
+		dirs = append(dirs, loc.path())
+	}
+	return dirs
+}
+
+func (s *dirScanner) Scan(idx *libraryIndex, app *AppInfo, m *matcher) []Finding {
+	var found []Finding
+	for _, loc := range s.locations {
+		dir := loc.path()
+		for _, entry := range idx.list(dir) {
+			if rule, ok := m.match(entry.Name(), loc.rules); ok {
+				found = append(found, newFinding(filepath.Join(dir, entry.Name()), s.id, rule))
+			}
+		}
+	}
+	return found
+}
+
+var scanners []Scanner
+
+func registerScanner(s Scanner) {
+	scanners = append(scanners, s)
+}
+
+func registeredScanners() []Scanner {
+	return scanners
+}
+
+func scannerByID(id string) Scanner {
+	for _, s := range scanners {
+		if s.ID() == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerScanner(&dirScanner{
+		id:   "associated-files",
+		name: "Associated files",
+		risk: riskMedium,
+		locations: []location{
+			homeLocation("Library/Preferences", matchBundleID, matchBundlePrefix),
+			homeLocation("Library/Application Support", matchBundleID, matchBundlePrefix, matchName, matchVendor),
+			homeLocation("Library/Caches", matchBundleID, matchBundlePrefix, matchName, matchVendor),
+			homeLocation("Library/Logs", matchBundleID, matchBundlePrefix, matchName),
+			homeLocation("Library/Saved Application State", matchBundleID),
+			homeLocation("Library/Containers", matchBundleID, matchBundlePrefix),
+			homeLocation("Library/Group Containers", matchBundleID, matchAppGroup, matchBundlePrefix, matchTeamID),
+		},
+	})
+	registerScanner(&dirScanner{
+		id:   "control-panels",
+		name: "Control Panels",
+		risk: riskMedium,
+		locations: []location{
+			homeLocation("Library/PreferencePanes", matchName),
+			systemLocation("/Library/PreferencePanes", matchName),
+		},
+	})
+	registerScanner(&launchdScanner{dirScanner{
+		id:   "startup-items",
+		name: "Startup Items",
+		risk: riskHigh,
+		locations: []location{
+			homeLocation("Library/LaunchAgents", matchBundleID, matchBundlePrefix, matchName, matchVendor),
+			systemLocation("/Library/LaunchAgents", matchBundleID, matchBundlePrefix, matchName, matchVendor),
+			homeLocation("Library/LaunchDaemons", matchBundleID, matchBundlePrefix, matchName, matchVendor),
+			systemLocation("/Library/LaunchDaemons", matchBundleID, matchBundlePrefix, matchName, matchVendor),
+		},
+	}})
+	registerScanner(&dirScanner{
+		id:   "quicklook-plugins",
+		name: "QuickLook Plugins",
+		risk: riskLow,
+		locations: []location{
+			homeLocation("Library/QuickLook", matchName),
+			systemLocation("/Library/QuickLook", matchName),
+		},
+	})
+	registerScanner(&dirScanner{
+		id:   "screen-savers",
+		name: "Screen Savers",
+		risk: riskLow,
+		locations: []location{
+			homeLocation("Library/Screen Savers", matchName),
+		},
+	})
+	registerScanner(&dirScanner{
+		id:   "input-methods",
+		name: "Input Methods",
+		risk: riskMedium,
+		locations: []location{
+			homeLocation("Library/Input Methods", matchName),
+			systemLocation("/Library/Input Methods", matchName),
+		},
+	})
+	registerScanner(&dirScanner{
+		id:   "fonts",
+		name: "Fonts",
+		risk: riskHigh,
+		locations: []location{
+			homeLocation("Library/Fonts", matchName),
+			systemLocation("/Library/Fonts", matchName),
+		},
+	})
+}
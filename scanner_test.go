@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegisteredScanners(t *testing.T) {
+	os.Setenv("HOME", "/Users/test")
+
+	seen := map[string]bool{}
+	for _, s := range registeredScanners() {
+		if seen[s.ID()] {
+			t.Errorf("duplicate scanner ID %s", s.ID())
+		}
+		seen[s.ID()] = true
+		if s.Name() == "" {
+			t.Errorf("scanner %s has no name", s.ID())
+		}
+		if len(s.Locations()) == 0 {
+			t.Errorf("scanner %s has no locations", s.ID())
+		}
+	}
+
+	startup := scannerByID("startup-items")
+	if startup == nil {
+		t.Fatal("expected startup-items scanner to be registered")
+	}
+	if startup.Risk() != riskHigh {
+		t.Errorf("expected startup items to be high risk, got %s", startup.Risk())
+	}
+	want := []string{
+		"/Users/test/Library/LaunchAgents",
+		"/Library/LaunchAgents",
+		"/Users/test/Library/LaunchDaemons",
+		"/Library/LaunchDaemons",
+	}
+	got := startup.Locations()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("location %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+type fakeScanner struct{ dir string }
+
+func (f fakeScanner) ID() string          { return "fake" }
+func (f fakeScanner) Name() string        { return "Fake Items" }
+func (f fakeScanner) Locations() []string { return []string{f.dir} }
+func (f fakeScanner) Risk() riskLevel     { return riskLow }
+func (f fakeScanner) Scan(idx *libraryIndex, app *AppInfo, m *matcher) []Finding {
+	return []Finding{newFinding(filepath.Join(f.dir, m.bundleIDs[0]), f.ID(), matchBundleID)}
+}
+
+func TestCustomScannerIsUsedGenerically(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	saved := scanners
+	registerScanner(fakeScanner{dir: fs.rootDir})
+	defer func() { scanners = saved }()
+
+	app := AppInfo{Name: "TestApp", Path: fs.createApp(t, "TestApp", "com.test.app")}
+	scanAssociatedFiles(context.Background(), &app)
+
+	cats := app.categories()
+	last := cats[len(cats)-1]
+	if last.Key != "fake" || last.Title != "Fake Items" {
+		t.Fatalf("expected custom category last, got %+v", last)
+	}
+	if len(last.Items) != 1 || last.Items[0].Path != filepath.Join(fs.rootDir, "com.test.app") {
+		t.Errorf("unexpected custom findings: %v", last.Items)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// markShared records which other installed apps match each of app's
+// findings at least as specifically as app itself does. Such items are
+// shared (a vendor folder used by a whole suite) or ambiguous (a name that
+// fits two products), so they are kept unless --include-shared is given.
+func markShared(app *AppInfo, installed []AppInfo) {
+	type candidate struct {
+		label string
+		m     *matcher
+	}
+	var others []candidate
+	for _, other := range installed {
+		if other.Path == app.Path {
+			continue
+		}
+		others = append(others, candidate{menuLabel(other, installed), newMatcher(&other, matchBundleIDFor(&other))})
+	}
+
+	for i := range app.Findings {
+		f := &app.Findings[i]
+		f.SharedWith = nil
+		entry := filepath.Base(f.Path)
+		for _, other := range others {
+			rule, ok := other.m.match(entry, matchRules)
+			if ok && rule <= f.Rule && !slices.Contains(f.SharedWith, other.label) {
+				f.SharedWith = append(f.SharedWith, other.label)
+			}
+		}
+	}
+}
+
+// kept reports whether f is left alone when its app is deleted.
+func (f Finding) kept() bool {
+	return f.keptReason() != ""
+}
+
+func (f Finding) keptReason() string {
+	switch {
+	case excluded(f.Path):
+		return "excluded in the zaap configuration"
+	case f.Protected != "" && !forceProtected:
+		return f.Protected
+	case len(f.SharedWith) > 0 && !includeShared:
+		return "also used by " + strings.Join(f.SharedWith, ", ")
+	}
+	return ""
+}
+
+// note explains why a finding is kept, or that it is deleted despite being
+// protected or shared.
+func (f Finding) note() string {
+	switch {
+	case f.kept():
+		return "kept: " + f.keptReason()
+	case f.Protected != "":
+		return "protected: " + f.Protected
+	case len(f.SharedWith) > 0:
+		return "shared with " + strings.Join(f.SharedWith, ", ")
+	}
+	return ""
+}
+
+// removeFinding removes f unless it is kept, reporting either way.
+func removeFinding(journal *journalSession, appName, category string, f Finding) deleteResult {
+	if !f.kept() {
+		return removeAndReport(journal, appName, category, f.Path)
+	}
+	if !jsonOutput() {
+		fmt.Printf("Kept: %s (%s)\n", f.Path, f.keptReason())
+	}
+	return deleteResult{Path: f.Path, Category: category, Action: "kept", Reason: f.keptReason()}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMarkShared(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	fs.createApp(t, "Microsoft Word", "com.microsoft.Word")
+	fs.createApp(t, "Microsoft Excel", "com.microsoft.Excel")
+	fs.createApp(t, "Pages", "com.apple.iWork.Pages")
+	fs.createApp(t, "Pages Helper", "com.example.pageshelper")
+
+	wordPref := fs.createPrefFile(t, "com.microsoft.Word", ".plist")
+	vendorDir := fs.createAppSupportDir(t, "Microsoft")
+	helperDir := fs.createAppSupportDir(t, "Pages Helper")
+	pagesPref := fs.createPrefFile(t, "com.apple.iWork.Pages", ".plist")
+
+	installed, err := getApplications(filepath.Join(fs.homeDir, "Applications"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byName := map[string]AppInfo{}
+	for _, app := range installed {
+		byName[app.Name] = app
+	}
+
+	word := byName["Microsoft Word"]
+	scanAssociatedFiles(context.Background(), &word)
+	markShared(&word, installed)
+	shared := map[string][]string{}
+	for _, f := range word.Findings {
+		shared[f.Path] = f.SharedWith
+	}
+	if _, ok := shared[vendorDir]; !ok {
+		t.Fatalf("expected %s to be found for Word, got %v", vendorDir, word.Findings)
+	}
+	if !reflect.DeepEqual(shared[vendorDir], []string{"Microsoft Excel"}) {
+		t.Errorf("expected vendor folder to be shared with Excel, got %v", shared[vendorDir])
+	}
+	if shared[wordPref] != nil {
+		t.Errorf("Word's own preferences should not be shared, got %v", shared[wordPref])
+	}
+
+	pages := byName["Pages"]
+	scanAssociatedFiles(context.Background(), &pages)
+	markShared(&pages, installed)
+	shared = map[string][]string{}
+	for _, f := range pages.Findings {
+		shared[f.Path] = f.SharedWith
+	}
+	if !reflect.DeepEqual(shared[helperDir], []string{"Pages Helper"}) {
+		t.Errorf("expected %s to be kept for Pages Helper, got %v", helperDir, shared[helperDir])
+	}
+	if shared[pagesPref] != nil {
+		t.Errorf("Pages preferences should not be shared, got %v", shared[pagesPref])
+	}
+}
+
+func TestRemoveFindingKeepsShared(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	outputFormat = "json"
+	defer func() { outputFormat = "text" }()
+
+	vendorDir := fs.createAppSupportDir(t, "Microsoft")
+	f := newFinding(vendorDir, "associated-files", matchVendor)
+	f.SharedWith = []string{"Microsoft Excel"}
+
+	result := removeFinding(nil, "Microsoft Word", "associated-files", f)
+	if result.Action != "kept" {
+		t.Errorf("expected shared item to be kept, got %+v", result)
+	}
+	if exists, _ := pathExists(vendorDir); !exists {
+		t.Fatal("shared item should not be removed")
+	}
+
+	includeShared = true
+	defer func() { includeShared = false }()
+	result = removeFinding(nil, "Microsoft Word", "associated-files", f)
+	if result.Action != "trashed" {
+		t.Errorf("expected --include-shared to remove the item, got %+v", result)
+	}
+	if exists, _ := pathExists(vendorDir); exists {
+		t.Error("shared item should be removed with --include-shared")
+	}
+}
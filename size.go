@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"syscall"
+)
+
+type inodeKey struct {
+	dev, ino uint64
+}
+
+// diskUsage returns the space path occupies on disk, walking directories
+// without following symlinks and counting hard-linked files once.
+func diskUsage(path string) int64 {
+	var total int64
+	seen := map[inodeKey]bool{}
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += allocatedSize(info, seen)
+		}
+		return nil
+	})
+	return total
+}
+
+// allocatedSize returns the blocks allocated to one file, or nothing if
+// another link to the same inode was already counted.
+func allocatedSize(info fs.FileInfo, seen map[inodeKey]bool) int64 {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size()
+	}
+	if !info.IsDir() && st.Nlink > 1 {
+		key := inodeKey{uint64(st.Dev), uint64(st.Ino)}
+		if seen[key] {
+			return 0
+		}
+		seen[key] = true
+	}
+	return int64(st.Blocks) * 512
+}
+
+// sizeApps fills in the disk usage of each app bundle.
+func sizeApps(ctx context.Context, apps []AppInfo) error {
+	return parallel(ctx, len(apps), func(i int) {
+		if apps[i].Path != "" {
+			apps[i].Size = diskUsage(apps[i].Path)
+		}
+	})
+}
+
+// formatSize renders bytes in decimal units, as Finder does.
+func formatSize(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	dir := t.TempDir()
+	big := filepath.Join(t.TempDir(), "big")
+	if err := os.WriteFile(big, make([]byte, 1<<20), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	data := filepath.Join(dir, "data")
+	if err := os.WriteFile(data, make([]byte, 10000), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Link(data, filepath.Join(dir, "hardlink")); err != nil {
+		t.Fatalf("failed to link: %v", err)
+	}
+	link := filepath.Join(dir, "symlink")
+	if err := os.Symlink(big, link); err != nil {
+		t.Fatalf("failed to symlink: %v", err)
+	}
+
+	lstatUsage := func(path string) int64 {
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatalf("lstat %s: %v", path, err)
+		}
+		return allocatedSize(info, map[inodeKey]bool{})
+	}
+
+	fileUsage := diskUsage(data)
+	if fileUsage < 10000 {
+		t.Fatalf("expected at least 10000 bytes for %s, got %d", data, fileUsage)
+	}
+	want := lstatUsage(dir) + fileUsage + lstatUsage(link)
+	if got := diskUsage(dir); got != want {
+		t.Errorf("expected %d bytes counting the hard link once and not following the symlink, got %d", want, got)
+	}
+	if got := diskUsage(link); got >= 1<<20 {
+		t.Errorf("symlink should not be followed, got %d bytes", got)
+	}
+}
+
+func TestReclaimable(t *testing.T) {
+	shared := Finding{Path: "/shared", Size: 300, SharedWith: []string{"Other"}}
+	app := AppInfo{
+		Size: 1000,
+		Findings: []Finding{
+			{Path: "/pref", Size: 20},
+			shared,
+		},
+	}
+	if got := app.reclaimable(); got != 1020 {
+		t.Errorf("expected shared items to be left out, got %d", got)
+	}
+
+	includeShared = true
+	defer func() { includeShared = false }()
+	if got := app.reclaimable(); got != 1320 {
+		t.Errorf("expected shared items to count with --include-shared, got %d", got)
+	}
+
+	c := category{Items: app.Findings}
+	if got := c.size(); got != 320 {
+		t.Errorf("expected category subtotal 320, got %d", got)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// moveToTrash moves path into the Trash for the volume it lives on and
+// returns where it ended up.
+func moveToTrash(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+
+	trashDir, err := trashDirFor(path, info)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(trashDir, 0700); err != nil {
+		return "", fmt.Errorf("creating trash %s: %w", trashDir, err)
+	}
+
+	dest, err := uniqueTrashPath(trashDir, filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// trashDirFor returns ~/.Trash for items on the home volume and
+// <mount>/.Trashes/<uid> for items on any other volume.
+func trashDirFor(path string, info os.FileInfo) (string, error) {
+	home := homeDir()
+	homeInfo, err := os.Stat(home)
+	if err != nil {
+		return "", fmt.Errorf("locating home trash: %w", err)
+	}
+
+	dev := deviceOf(info)
+	if dev == deviceOf(homeInfo) {
+		return filepath.Join(home, ".Trash"), nil
+	}
+
+	mount, err := mountPoint(path, dev)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(mount, ".Trashes", strconv.Itoa(targetUID())), nil
+}
+
+// mountPoint walks up from path to the topmost directory still on dev.
+func mountPoint(path string, dev uint64) (string, error) {
+	dir := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir, nil
+		}
+		info, err := os.Lstat(parent)
+		if err != nil {
+			return "", err
+		}
+		if deviceOf(info) != dev {
+			return dir, nil
+		}
+		dir = parent
+	}
+}
+
+func deviceOf(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev)
+	}
+	return 0
+}
+
+// uniqueTrashPath picks a name in dir that is not taken yet, numbering
+// duplicates the way Finder does ("Foo.app", "Foo 2.app", ...).
+func uniqueTrashPath(dir, name string) (string, error) {
+	stem, ext := name, ""
+	if i := strings.LastIndex(name, "."); i > 0 {
+		stem, ext = name[:i], name[i:]
+	}
+
+	candidate := filepath.Join(dir, name)
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s %d%s", stem, n, ext))
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveToTrashCollisions(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	var dests []string
+	for i := 0; i < 3; i++ {
+		appPath := fs.createApp(t, "TestApp", "com.test.app")
+		dest, err := moveToTrash(appPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dests = append(dests, dest)
+	}
+
+	trash := filepath.Join(fs.homeDir, ".Trash")
+	want := []string{
+		filepath.Join(trash, "TestApp.app"),
+		filepath.Join(trash, "TestApp 2.app"),
+		filepath.Join(trash, "TestApp 3.app"),
+	}
+	for i := range want {
+		if dests[i] != want[i] {
+			t.Errorf("move %d: expected %s, got %s", i+1, want[i], dests[i])
+		}
+		if _, err := os.Stat(filepath.Join(dests[i], "Contents", "Info.plist")); err != nil {
+			t.Errorf("move %d: bundle contents missing from trash: %v", i+1, err)
+		}
+	}
+}
+
+func TestUniqueTrashPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{".zoomus", "notes", "a.plist"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+
+	tests := map[string]string{
+		".zoomus":  ".zoomus 2",
+		"notes":    "notes 2",
+		"a.plist":  "a 2.plist",
+		"new.file": "new.file",
+	}
+	for name, want := range tests {
+		got, err := uniqueTrashPath(dir, name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != filepath.Join(dir, want) {
+			t.Errorf("uniqueTrashPath(%q) = %s, want %s", name, got, filepath.Join(dir, want))
+		}
+	}
+}
+
+func TestTrashDirForHomeVolume(t *testing.T) {
+	fs := newTestFS(t)
+	os.Setenv("HOME", fs.homeDir)
+
+	path := fs.createPrefFile(t, "com.test.app", ".plist")
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dir, err := trashDirFor(path, info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dir != filepath.Join(fs.homeDir, ".Trash") {
+		t.Errorf("expected home trash, got %s", dir)
+	}
+
+	mount, err := mountPoint(path, deviceOf(info))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rel, err := filepath.Rel(mount, path); err != nil || rel == path {
+		t.Errorf("mount point %s does not contain %s", mount, path)
+	}
+}